@@ -0,0 +1,49 @@
+// Command index-hashtags is the former name of index-entities, kept because migration 013 says to run it
+// It indexes the hashtags and the mentions of existing tweets, exactly like index-entities, with the same options.
+//
+// Usage:
+//
+//	go run ./cmd/index-hashtags
+package main
+
+import (
+	"GO-X/models"  // Import the models package to index the tweets
+	"database/sql" // Import the database/sql package to interact with the SQL database
+	"flag"         // Import the flag package to read command line options
+	"log"          // Import the log package for logging errors and info
+
+	_ "github.com/go-sql-driver/mysql" // Blank import to initialize the MySQL driver
+)
+
+func main() {
+	dsn := flag.String("dsn", "root:@tcp(localhost:3306)/GO-X?parseTime=true", "MySQL data source name")
+	batch := flag.Int("batch", 500, "number of tweets read at a time")
+	flag.Parse()
+
+	if *batch < 1 {
+		flag.Usage()
+		log.Fatal("-batch must be at least 1")
+	}
+	log.Println("index-hashtags was renamed index-entities; use go run ./cmd/index-entities next time")
+
+	db, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		log.Fatal("Error opening the database: ", err)
+	}
+	defer db.Close()
+
+	// Walk the tweets in ID order, one batch at a time
+	afterID := 0
+	for {
+		lastID, err := models.IndexEntitiesAfter(db, afterID, *batch)
+		if err != nil {
+			log.Fatalf("Error indexing the tweets after ID %d: %v", afterID, err)
+		}
+		if lastID == 0 {
+			break
+		}
+		afterID = lastID
+		log.Printf("Indexed tweets up to ID %d", afterID)
+	}
+	log.Println("Hashtag and mention indexes are up to date")
+}
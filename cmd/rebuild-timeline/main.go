@@ -0,0 +1,56 @@
+// Command rebuild-timeline recomputes materialized home timelines stored in the timeline_entries table
+//
+// Usage:
+//
+//	go run ./cmd/rebuild-timeline -user 42
+//	go run ./cmd/rebuild-timeline -all
+package main
+
+import (
+	"GO-X/models"   // Import the models package to list users
+	"GO-X/timeline" // Import the timeline package that does the rebuild
+	"database/sql"  // Import the database/sql package to interact with the SQL database
+	"flag"          // Import the flag package to read command line options
+	"log"           // Import the log package for logging errors and info
+
+	_ "github.com/go-sql-driver/mysql" // Blank import to initialize the MySQL driver
+)
+
+func main() {
+	dsn := flag.String("dsn", "root:@tcp(localhost:3306)/GO-X?parseTime=true", "MySQL data source name")
+	userID := flag.Int("user", 0, "ID of the user whose timeline is rebuilt")
+	all := flag.Bool("all", false, "rebuild the timeline of every user")
+	size := flag.Int("size", 800, "number of entries kept per timeline")
+	flag.Parse()
+
+	if *userID == 0 && !*all {
+		flag.Usage()
+		log.Fatal("either -user or -all is required")
+	}
+
+	db, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		log.Fatal("Error opening the database: ", err)
+	}
+	defer db.Close()
+
+	// The celebrity threshold doesn't matter for a rebuild, every followed account is included
+	service := timeline.NewService(db, timeline.NewSQLStore(db, *size), 0)
+	service.RebuildSize = *size
+
+	userIDs := []int{*userID}
+	if *all {
+		userIDs, err = models.GetAllUserIDs(db)
+		if err != nil {
+			log.Fatal("Error listing users: ", err)
+		}
+	}
+
+	for _, id := range userIDs {
+		count, err := service.Rebuild(id)
+		if err != nil {
+			log.Fatalf("Error rebuilding the timeline of user %d: %v", id, err)
+		}
+		log.Printf("Rebuilt the timeline of user %d (%d entries)", id, count)
+	}
+}
@@ -0,0 +1,54 @@
+// Command set-role changes the role of an account, e.g. to promote the first admin
+//
+// Usage:
+//
+//	go run ./cmd/set-role -user alice -role admin
+//	go run ./cmd/set-role -user bob -role user
+package main
+
+import (
+	"GO-X/models"  // Import the models package to look up the user and change their role
+	"GO-X/utils"   // Import the utils package to revoke the tokens that carry the old role
+	"database/sql" // Import the database/sql package to interact with the SQL database
+	"flag"         // Import the flag package to read command line options
+	"log"          // Import the log package for logging errors and info
+	"time"         // Import the time package for the revocation cut-off
+
+	_ "github.com/go-sql-driver/mysql" // Blank import to initialize the MySQL driver
+)
+
+func main() {
+	dsn := flag.String("dsn", "root:@tcp(localhost:3306)/GO-X?parseTime=true", "MySQL data source name")
+	username := flag.String("user", "", "username of the account to change")
+	role := flag.String("role", "", "new role: user, moderator or admin")
+	flag.Parse()
+
+	if *username == "" || !models.ValidRole(*role) {
+		flag.Usage()
+		log.Fatal("-user and a valid -role are required")
+	}
+
+	db, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		log.Fatal("Error opening the database: ", err)
+	}
+	defer db.Close()
+
+	user, err := models.GetUserByUsername(db, *username)
+	if err != nil {
+		log.Fatal("Error fetching user: ", err)
+	}
+	if user == nil {
+		log.Fatalf("No user named %q", *username)
+	}
+
+	if err := models.SetUserRole(db, user.ID, *role); err != nil {
+		log.Fatal("Error setting role: ", err)
+	}
+
+	// Access tokens carry the role: revoke them (in the SQL store used by the server) so the next refresh picks up the new one
+	if err := utils.NewSQLRevocationStore(db).RevokeUser(user.Username, time.Now().Truncate(time.Millisecond)); err != nil {
+		log.Fatal("Error revoking tokens: ", err)
+	}
+	log.Printf("Role of %q changed from %q to %q", user.Username, user.Role, *role)
+}
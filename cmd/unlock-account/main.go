@@ -0,0 +1,55 @@
+// Command unlock-account lifts the lockout of an account, or of a client IP, after failed logins
+//
+// Usage:
+//
+//	go run ./cmd/unlock-account -user alice
+//	go run ./cmd/unlock-account -ip 203.0.113.7
+package main
+
+import (
+	"GO-X/models"  // Import the models package to clear failed logins
+	"database/sql" // Import the database/sql package to interact with the SQL database
+	"flag"         // Import the flag package to read command line options
+	"log"          // Import the log package for logging errors and info
+	"strings"      // Import the strings package to normalize usernames
+
+	_ "github.com/go-sql-driver/mysql" // Blank import to initialize the MySQL driver
+)
+
+func main() {
+	dsn := flag.String("dsn", "root:@tcp(localhost:3306)/GO-X?parseTime=true", "MySQL data source name")
+	username := flag.String("user", "", "username of the account to unlock")
+	ip := flag.String("ip", "", "client IP address to unlock")
+	flag.Parse()
+
+	if *username == "" && *ip == "" {
+		flag.Usage()
+		log.Fatal("either -user or -ip is required")
+	}
+
+	db, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		log.Fatal("Error opening the database: ", err)
+	}
+	defer db.Close()
+
+	if *username != "" {
+		unlock(db, models.LoginScopeUser, strings.ToLower(*username))
+	}
+	if *ip != "" {
+		unlock(db, models.LoginScopeIP, *ip)
+	}
+}
+
+// unlock clears the failed logins of one account or IP
+func unlock(db *sql.DB, scope string, subject string) {
+	cleared, err := models.ClearLoginFailures(db, scope, subject)
+	if err != nil {
+		log.Fatalf("Error unlocking %s %q: %v", scope, subject, err)
+	}
+	if cleared {
+		log.Printf("Unlocked %s %q", scope, subject)
+	} else {
+		log.Printf("No failed logins recorded for %s %q", scope, subject)
+	}
+}
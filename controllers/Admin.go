@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"GO-X/models" // Import the models package to read and moderate accounts and tweets
+	"GO-X/utils"  // Import the utils package to encode cursors and revoke tokens
+	"log"         // Import the log package to print error messages
+	"strings"     // To normalize usernames before clearing failed logins
+
+	"github.com/go-playground/validator/v10" // Import Go validator package for input validation
+	"github.com/gofiber/fiber/v2"            // Import the Fiber web framework to handle HTTP requests
+)
+
+// RoleRequest struct defines the expected data to change the role of a user
+type RoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user moderator admin"`
+}
+
+// AdminListUsers handles GET /admin/users
+// It lists every account, most recent first, with cursor pagination; ?role= keeps only one role
+func AdminListUsers(c *fiber.Ctx) error {
+	role := c.Query("role")
+	if role != "" && !models.ValidRole(role) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid role",
+		})
+	}
+
+	var cursor idCursor
+	if !parseCursor(c, &cursor) {
+		return invalidCursor(c)
+	}
+	limit := parseLimit(c)
+
+	users, err := models.ListUsers(db, role, cursor.ID, limit)
+	if err != nil {
+		log.Println("Error listing users:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	// A full page means there may be more: hand out a cursor pointing after the last row
+	var nextCursor string
+	if len(users) == limit {
+		nextCursor = utils.EncodeCursor(idCursor{ID: users[len(users)-1].ID})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"users":       users,
+		"next_cursor": nextCursor,
+	})
+}
+
+// SuspendUser handles POST /admin/users/:id/suspend
+// A suspended user is logged out everywhere and can't log in again until the suspension is lifted
+func SuspendUser(c *fiber.Ctx) error {
+	target, err := loadModerationTarget(c)
+	if target == nil {
+		return err
+	}
+
+	suspended, err := models.SuspendUser(db, target.ID)
+	if err != nil {
+		log.Println("Error suspending user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to suspend user",
+		})
+	}
+	if !suspended {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":  "success",
+			"message": "User is already suspended",
+		})
+	}
+
+	// End every session of the user right away, instead of waiting for their access token to expire
+	if err := revokeAllSessions(target); err != nil {
+		log.Println("Error revoking sessions of suspended user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "User suspended, but their sessions could not be revoked",
+		})
+	}
+	log.Printf("User %q suspended by %q", target.Username, getClaimsUsername(c))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "User suspended successfully",
+	})
+}
+
+// UnsuspendUser handles POST /admin/users/:id/unsuspend
+// The user can log in again; their old sessions stay revoked
+func UnsuspendUser(c *fiber.Ctx) error {
+	target, err := loadModerationTarget(c)
+	if target == nil {
+		return err
+	}
+
+	unsuspended, err := models.UnsuspendUser(db, target.ID)
+	if err != nil {
+		log.Println("Error unsuspending user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to unsuspend user",
+		})
+	}
+	if !unsuspended {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":  "success",
+			"message": "User is not suspended",
+		})
+	}
+	log.Printf("User %q unsuspended by %q", target.Username, getClaimsUsername(c))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "User unsuspended successfully",
+	})
+}
+
+// SetUserRole handles PUT /admin/users/:id/role
+// Admins can't change the role of another admin here; use cmd/set-role for that
+func SetUserRole(c *fiber.Ctx) error {
+	target, err := loadModerationTarget(c)
+	if target == nil {
+		return err
+	}
+
+	// Check if the request body is empty
+	if c.Body() == nil || len(c.Body()) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Request body cannot be empty",
+		})
+	}
+
+	// Parse the incoming request body into the RoleRequest struct
+	var roleRequest RoleRequest
+	if err := c.BodyParser(&roleRequest); err != nil {
+		log.Println("BodyParser error:", err)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Review your input",
+			"errors":  err.Error(),
+		})
+	}
+
+	// Validate the parsed input using the Go validator package
+	validate := validator.New()
+	if err := validate.Struct(roleRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+		})
+	}
+
+	if err := models.SetUserRole(db, target.ID, roleRequest.Role); err != nil {
+		log.Println("Error setting role:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to change role",
+		})
+	}
+
+	// Access tokens carry the role, so revoke them; the next refresh issues one with the new role
+	if err := utils.RevokeAllTokens(target.Username); err != nil {
+		log.Println("Error revoking tokens after role change:", err)
+	}
+	log.Printf("Role of %q changed from %q to %q by %q", target.Username, target.Role, roleRequest.Role, getClaimsUsername(c))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Role changed successfully",
+		"role":    roleRequest.Role,
+	})
+}
+
+// UnlockUser handles POST /admin/users/:id/unlock
+// It lifts the lockout of an account after failed logins (like cmd/unlock-account)
+func UnlockUser(c *fiber.Ctx) error {
+	target, err := loadModerationTarget(c)
+	if target == nil {
+		return err
+	}
+
+	cleared, err := models.ClearLoginFailures(db, models.LoginScopeUser, strings.ToLower(target.Username))
+	if err != nil {
+		log.Println("Error clearing failed logins:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to unlock user",
+		})
+	}
+	if !cleared {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":  "success",
+			"message": "No failed logins recorded for this user",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "User unlocked successfully",
+	})
+}
+
+// AdminDeleteTweet handles DELETE /admin/tweets/:id
+// It removes any tweet, whoever wrote it
+func AdminDeleteTweet(c *fiber.Ctx) error {
+	tweetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid tweet ID",
+		})
+	}
+
+	tweet, err := models.GetTweetByID(db, tweetID)
+	if err != nil {
+		log.Println("Error fetching tweet:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if tweet == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Tweet not found",
+		})
+	}
+
+	if err := removeTweet(tweet); err != nil {
+		log.Println("Error deleting tweet:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to delete tweet",
+		})
+	}
+	log.Printf("Tweet %d of %q deleted by %q", tweet.ID, tweet.Username, getClaimsUsername(c))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Tweet deleted successfully",
+	})
+}
+
+// loadModerationTarget loads the user named by the ":id" parameter and checks the authenticated user may act on them
+// Nobody can moderate their own account, and only users of a lower role can be moderated,
+// so moderators can't suspend each other and admins can only be changed from the command line.
+// When the target can't be used it writes the error response and returns a nil user.
+func loadModerationTarget(c *fiber.Ctx) (*models.User, error) {
+	targetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid user ID",
+		})
+	}
+
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return nil, err
+	}
+	if user.ID == targetID {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "You cannot moderate your own account",
+		})
+	}
+
+	target, err := models.GetUserByID(db, targetID)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if target == nil {
+		return nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "User not found",
+		})
+	}
+
+	// The role is read from the database, not the token, so a user demoted a moment ago has no power left
+	if !models.RoleOutranks(user.Role, target.Role) {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "You cannot moderate a user with the same or a higher role",
+		})
+	}
+	return target, nil
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"GO-X/models"   // Import the models package to store blocks
+	"GO-X/timeline" // Import the timeline package to update timelines after a block
+	"log"           // Import the log package to print error messages
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// BlockUser handles POST /users/:id/block
+// Blocking removes the follows between the two users, both ways, and keeps the blocked user from following
+// again; the blocker's tweets then show as tombstones in the quotes the blocked user sees.
+// Blocking someone twice is not an error.
+func BlockUser(c *fiber.Ctx) error {
+	user, target, err := loadUserTarget(c, "You cannot block yourself")
+	if target == nil {
+		return err
+	}
+
+	blocked, err := models.BlockUser(db, user.ID, target.ID)
+	if err != nil {
+		log.Println("Error blocking user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	message := "User blocked"
+	if !blocked {
+		message = "User already blocked"
+	} else {
+		// Take the tweets of each user out of the other's timeline, as the follows are gone
+		updateTimelines("block", func(service *timeline.Service) error {
+			if err := service.OnUnfollow(user.ID, target.ID); err != nil {
+				return err
+			}
+			return service.OnUnfollow(target.ID, user.ID)
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+	})
+}
+
+// UnblockUser handles POST /users/:id/unblock
+// The follows removed by the block are not restored
+func UnblockUser(c *fiber.Ctx) error {
+	user, target, err := loadUserTarget(c, "You cannot unblock yourself")
+	if target == nil {
+		return err
+	}
+
+	unblocked, err := models.UnblockUser(db, user.ID, target.ID)
+	if err != nil {
+		log.Println("Error unblocking user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	message := "User unblocked"
+	if !unblocked {
+		message = "User was not blocked"
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+	})
+}
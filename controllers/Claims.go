@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"GO-X/models" // Import the models package to load the authenticated user
+	"log"         // Import the log package to print error messages
+
+	"github.com/gofiber/fiber/v2"  // Import the Fiber web framework to read the request context
+	"github.com/golang-jwt/jwt/v4" // Import the JWT library for the claims type
+)
+
+// getClaims returns the JWT claims stored in the context by middleware.ProtectRoute
+// It returns nil when the route isn't protected
+func getClaims(c *fiber.Ctx) jwt.MapClaims {
+	claims, _ := c.Locals("claims").(jwt.MapClaims)
+	return claims
+}
+
+// getClaimsUsername returns the username of the authenticated user
+func getClaimsUsername(c *fiber.Ctx) string {
+	username, _ := getClaims(c)["username"].(string)
+	return username
+}
+
+// getCurrentUser loads the authenticated user from the database
+// It returns nil (and no error) when the user behind the token no longer exists
+func getCurrentUser(c *fiber.Ctx) (*models.User, error) {
+	return models.GetUserByUsername(db, getClaimsUsername(c))
+}
+
+// loadCurrentUser loads the authenticated user
+// When the user can't be loaded it writes the error response and returns a nil user
+func loadCurrentUser(c *fiber.Ctx) (*models.User, error) {
+	user, err := getCurrentUser(c)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+	}
+	return user, nil
+}
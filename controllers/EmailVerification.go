@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"GO-X/mailer" // Import the mailer package to send the verification link by email
+	"GO-X/models" // Import the models package to store verification tokens
+	"GO-X/utils"  // Import the utils package to generate and hash tokens
+	"log"         // Import the log package to print error messages
+	"net/url"     // To put the token in the verification link
+	"time"        // To compute the expiration time of verification tokens
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// emailVerificationTTL is how long a verification link stays valid after it was emailed
+const emailVerificationTTL = 24 * time.Hour
+
+// publicURL is the address of the API as seen by users, used to build the links sent by email
+var publicURL = "http://localhost:8000"
+
+// SetPublicURL sets the address of the API used in the links sent by email (e.g. "https://api.example.com")
+func SetPublicURL(u string) {
+	publicURL = u
+}
+
+// sendEmailVerification creates a verification token for the user and emails them the link
+func sendEmailVerification(user *models.User) error {
+	// Generate a random token; only its hash is stored in the database
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return err
+	}
+	if err := models.CreateEmailVerification(db, user.ID, utils.HashToken(token), time.Now().Add(emailVerificationTTL)); err != nil {
+		return err
+	}
+
+	link := publicURL + "/auth/verify-email?token=" + url.QueryEscape(token)
+	return mail.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Open the following link to verify your email address. It expires in 24 hours.\n\n" +
+			link + "\n\n" +
+			"If you didn't create an account, you can ignore this email.",
+	})
+}
+
+// VerifyEmail handles GET /auth/verify-email
+// It is the link emailed after registration: ?token= is checked and the email address marked as verified
+func VerifyEmail(c *fiber.Ctx) error {
+	token := c.Query("token")
+	if token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Missing verification token",
+		})
+	}
+
+	// Find the token and remove it, so it can't be used a second time
+	verification, err := models.ConsumeEmailVerification(db, utils.HashToken(token))
+	if err != nil {
+		log.Println("Error consuming verification token:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if verification == nil || verification.Expired() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired verification link",
+		})
+	}
+
+	if err := models.MarkEmailVerified(db, verification.UserID); err != nil {
+		log.Println("Error marking email as verified:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to verify email",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Email verified successfully",
+	})
+}
+
+// ResendEmailVerification handles POST /auth/resend-verification
+// It emails a new verification link to the authenticated user; the previous link stops working
+func ResendEmailVerification(c *fiber.Ctx) error {
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":  "success",
+			"message": "Email already verified",
+		})
+	}
+
+	if err := sendEmailVerification(user); err != nil {
+		log.Println("Error sending verification email:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to send verification email",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Verification email sent",
+	})
+}
@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"GO-X/models"   // Import the models package to store likes and retweets
+	"GO-X/realtime" // Import the realtime package to notify the author of the tweet
+	"GO-X/timeline" // Import the timeline package to fan retweets out
+	"GO-X/utils"    // Import the utils package to build pagination cursors
+	"database/sql"  // Import the sql package for the signature of the model functions
+	"log"           // Import the log package to print error messages
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// engagementAction is the signature shared by models.LikeTweet, models.UnlikeTweet, models.RetweetTweet...
+// The boolean is false when the action didn't change anything (e.g. liking a tweet twice)
+type engagementAction func(db *sql.DB, userID int, tweetID int) (bool, error)
+
+// engagementLister is the signature shared by models.GetTweetLikes and models.GetTweetRetweets
+type engagementLister func(db *sql.DB, tweetID int, beforeID int, limit int) ([]models.Engagement, error)
+
+// LikeTweet handles POST /tweets/:id/like
+// Liking a tweet twice is not an error, the second call just reports that it was already liked
+func LikeTweet(c *fiber.Ctx) error {
+	return engage(c, models.LikeTweet, "Tweet liked", "Tweet already liked", func(user *models.User, tweet *models.Tweet) {
+		notifyAuthor(realtime.EventLike, models.NotificationLike, user, tweet)
+	})
+}
+
+// UnlikeTweet handles POST /tweets/:id/unlike
+func UnlikeTweet(c *fiber.Ctx) error {
+	return engage(c, models.UnlikeTweet, "Tweet unliked", "Tweet was not liked", nil)
+}
+
+// RetweetTweet handles POST /tweets/:id/retweet
+// Retweeting a tweet twice is not an error, the second call just reports that it was already retweeted
+func RetweetTweet(c *fiber.Ctx) error {
+	return engage(c, models.RetweetTweet, "Tweet retweeted", "Tweet already retweeted", func(user *models.User, tweet *models.Tweet) {
+		updateTimelines("retweet", func(service *timeline.Service) error {
+			return service.OnRetweet(user.ID, tweet.ID)
+		})
+		notifyAuthor(realtime.EventRetweet, models.NotificationRetweet, user, tweet)
+	})
+}
+
+// UnretweetTweet handles POST /tweets/:id/unretweet
+func UnretweetTweet(c *fiber.Ctx) error {
+	return engage(c, models.UnretweetTweet, "Retweet removed", "Tweet was not retweeted", func(user *models.User, tweet *models.Tweet) {
+		updateTimelines("unretweet", func(service *timeline.Service) error {
+			return service.OnUnretweet(user.ID, tweet.ID)
+		})
+	})
+}
+
+// GetTweetLikes handles GET /tweets/:id/likes
+// It lists the users who liked the tweet, newest first, with cursor pagination
+func GetTweetLikes(c *fiber.Ctx) error {
+	return listEngagements(c, models.GetTweetLikes, "likes")
+}
+
+// GetTweetRetweets handles GET /tweets/:id/retweets
+// It lists the users who retweeted the tweet, newest first, with cursor pagination
+func GetTweetRetweets(c *fiber.Ctx) error {
+	return listEngagements(c, models.GetTweetRetweets, "retweets")
+}
+
+// engage runs a like/unlike/retweet/unretweet action for the authenticated user
+// It always answers 200 with the fresh counters, whether or not the action changed anything.
+// onChange, when not nil, is called only if the action actually changed something.
+func engage(c *fiber.Ctx, action engagementAction, doneMessage string, unchangedMessage string, onChange func(user *models.User, tweet *models.Tweet)) error {
+	tweet, user, err := loadTweetForUser(c)
+	if tweet == nil {
+		return err
+	}
+
+	changed, err := action(db, user.ID, tweet.ID)
+	if err != nil {
+		log.Println("Error updating engagement:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	message := doneMessage
+	if !changed {
+		message = unchangedMessage
+	} else if onChange != nil {
+		onChange(user, tweet)
+	}
+
+	// Reload the tweet so the counters include this action
+	updated, err := models.GetTweetByID(db, tweet.ID)
+	if err != nil {
+		log.Println("Error fetching tweet:", err)
+	}
+	if updated != nil {
+		tweet = updated
+	}
+	if shown, err := hideBlockedQuotes(c, user.ID, tweet); !shown {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"tweet":   tweet,
+	})
+}
+
+// notifyAuthor tells the author of a tweet that someone liked or retweeted it
+// The author gets a real-time event and a notification in their inbox.
+// Users are not notified about their own actions
+func notifyAuthor(eventType string, notificationType string, user *models.User, tweet *models.Tweet) {
+	if tweet.UserID == user.ID {
+		return
+	}
+	recordNotification(tweet.UserID, user.ID, notificationType, tweet.ID)
+	publishTweetEvent(tweet.UserID, eventType, fiber.Map{
+		"user":  models.UserRef{ID: user.ID, Username: user.Username},
+		"tweet": tweet,
+	})
+}
+
+// listEngagements answers a paginated list of users who liked or retweeted a tweet
+func listEngagements(c *fiber.Ctx, lister engagementLister, key string) error {
+	tweet, _, err := loadTweetForUser(c)
+	if tweet == nil {
+		return err
+	}
+
+	var cursor idCursor
+	if !parseCursor(c, &cursor) {
+		return invalidCursor(c)
+	}
+	limit := parseLimit(c)
+
+	engagements, err := lister(db, tweet.ID, cursor.ID, limit)
+	if err != nil {
+		log.Println("Error listing engagements:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	// A full page means there may be more: hand out a cursor pointing after the last row
+	var nextCursor string
+	if len(engagements) == limit {
+		nextCursor = utils.EncodeCursor(idCursor{ID: engagements[len(engagements)-1].ID})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		key:           engagements,
+		"next_cursor": nextCursor,
+	})
+}
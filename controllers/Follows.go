@@ -0,0 +1,235 @@
+package controllers
+
+import (
+	"GO-X/models"   // Import the models package to store follows and load profiles
+	"GO-X/realtime" // Import the realtime package to notify the followed user
+	"GO-X/timeline" // Import the timeline package to update timelines after a follow
+	"GO-X/utils"    // Import the utils package to build pagination cursors
+	"database/sql"  // Import the sql package for the signature of the model functions
+	"log"           // Import the log package to print error messages
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// followLister is the signature shared by models.GetFollowers and models.GetFollowing
+type followLister func(db *sql.DB, userID int, beforeID int, limit int) ([]models.FollowEntry, error)
+
+// FollowUser handles POST /users/:id/follow
+// Following someone twice is not an error; users can't follow themselves, nor someone they block or who blocks them
+func FollowUser(c *fiber.Ctx) error {
+	user, target, err := loadFollowTarget(c)
+	if target == nil {
+		return err
+	}
+
+	blocked, err := models.IsBlockedEitherWay(db, user.ID, target.ID)
+	if err != nil {
+		log.Println("Error checking block:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if blocked {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "You can't follow this user",
+		})
+	}
+
+	followed, err := models.FollowUser(db, user.ID, target.ID)
+	if err != nil {
+		log.Println("Error following user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	message := "User followed"
+	if !followed {
+		message = "User already followed"
+	} else {
+		// Copy the recent tweets of the followed account into the follower's timeline
+		updateTimelines("follow", func(service *timeline.Service) error {
+			return service.OnFollow(user.ID, target.ID)
+		})
+		recordNotification(target.ID, user.ID, models.NotificationFollow, 0)
+		publishEvent(target.ID, realtime.EventFollow, fiber.Map{
+			"user": models.UserRef{ID: user.ID, Username: user.Username},
+		})
+	}
+	return followResponse(c, target.ID, message)
+}
+
+// UnfollowUser handles POST /users/:id/unfollow
+func UnfollowUser(c *fiber.Ctx) error {
+	user, target, err := loadFollowTarget(c)
+	if target == nil {
+		return err
+	}
+
+	unfollowed, err := models.UnfollowUser(db, user.ID, target.ID)
+	if err != nil {
+		log.Println("Error unfollowing user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	message := "User unfollowed"
+	if !unfollowed {
+		message = "User was not followed"
+	} else {
+		updateTimelines("unfollow", func(service *timeline.Service) error {
+			return service.OnUnfollow(user.ID, target.ID)
+		})
+	}
+	return followResponse(c, target.ID, message)
+}
+
+// GetFollowers handles GET /users/:id/followers
+// It lists the followers of the user, most recent first, with cursor pagination
+func GetFollowers(c *fiber.Ctx) error {
+	return listFollows(c, models.GetFollowers, "followers")
+}
+
+// GetFollowing handles GET /users/:id/following
+// It lists the users the user follows, most recent first, with cursor pagination
+func GetFollowing(c *fiber.Ctx) error {
+	return listFollows(c, models.GetFollowing, "following")
+}
+
+// loadFollowTarget loads the authenticated user and the user named by the ":id" parameter
+// When the follow can't happen (bad ID, unknown user, self-follow) it writes the error response and returns a nil target
+func loadFollowTarget(c *fiber.Ctx) (*models.User, *models.User, error) {
+	return loadUserTarget(c, "You cannot follow yourself")
+}
+
+// loadUserTarget loads the authenticated user and the user named by the ":id" parameter, who must be someone else
+// When either can't be loaded it writes the error response (selfMessage when they are the same user) and returns a nil target
+func loadUserTarget(c *fiber.Ctx, selfMessage string) (*models.User, *models.User, error) {
+	targetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid user ID",
+		})
+	}
+
+	user, err := getCurrentUser(c)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		return nil, nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+	}
+
+	// A user can't follow (or unfollow, block...) themselves
+	if user.ID == targetID {
+		return nil, nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": selfMessage,
+		})
+	}
+
+	target, err := models.GetUserByID(db, targetID)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if target == nil {
+		return nil, nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "User not found",
+		})
+	}
+	return user, target, nil
+}
+
+// followResponse answers a follow or unfollow with the fresh profile of the target
+func followResponse(c *fiber.Ctx, targetID int, message string) error {
+	profile, err := models.GetUserProfile(db, targetID)
+	if err != nil {
+		log.Println("Error fetching profile:", err)
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"user":    profile,
+	})
+}
+
+// listFollows answers a paginated followers or following list
+// The profile of the user the list belongs to is included, with its counts
+func listFollows(c *fiber.Ctx, lister followLister, key string) error {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid user ID",
+		})
+	}
+
+	var cursor idCursor
+	if !parseCursor(c, &cursor) {
+		return invalidCursor(c)
+	}
+	limit := parseLimit(c)
+
+	profile, err := models.GetUserProfile(db, userID)
+	if err != nil {
+		log.Println("Error fetching profile:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if profile == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "User not found",
+		})
+	}
+
+	// The follow lists of an account hiding its details are only shown to its owner and their followers
+	if profile.HideProfileDetails {
+		allowed, err := canSeeProfileDetails(c, profile.ID)
+		if !allowed {
+			return err
+		}
+	}
+
+	entries, err := lister(db, userID, cursor.ID, limit)
+	if err != nil {
+		log.Println("Error listing follows:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	// A full page means there may be more: hand out a cursor pointing after the last row
+	var nextCursor string
+	if len(entries) == limit {
+		nextCursor = utils.EncodeCursor(idCursor{ID: entries[len(entries)-1].ID})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"user":        profile,
+		key:           entries,
+		"next_cursor": nextCursor,
+	})
+}
@@ -0,0 +1,63 @@
+package controllers
+
+import (
+	"GO-X/entities" // Import the entities package to normalize the requested tag
+	"GO-X/models"   // Import the models package to list the tweets of a hashtag
+	"GO-X/utils"    // Import the utils package to build pagination cursors
+	"log"           // Import the log package to print error messages
+	"net/url"       // To decode tags written in other scripts, which arrive percent-encoded
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// GetHashtagTweets handles GET /hashtags/:tag
+// It lists the tweets carrying the hashtag, most recent first, with cursor pagination.
+// The tag may be sent with or without "#" (encoded as %23) and in any case: /hashtags/GoLang lists #golang.
+func GetHashtagTweets(c *fiber.Ctx) error {
+	tag, err := url.PathUnescape(c.Params("tag"))
+	if err == nil {
+		tag = entities.NormalizeHashtag(tag)
+	}
+	if err != nil || tag == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid hashtag",
+		})
+	}
+
+	var cursor idCursor
+	if !parseCursor(c, &cursor) {
+		return invalidCursor(c)
+	}
+	limit := parseLimit(c)
+
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	tweets, err := models.GetHashtagTweets(db, tag, cursor.ID, limit)
+	if err != nil {
+		log.Println("Error listing hashtag tweets:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if shown, err := hideBlockedQuotes(c, user.ID, tweets...); !shown {
+		return err
+	}
+
+	// A full page means there may be more: hand out a cursor pointing after the last tweet
+	var nextCursor string
+	if len(tweets) == limit {
+		nextCursor = utils.EncodeCursor(idCursor{ID: tweets[len(tweets)-1].ID})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"hashtag":     tag,
+		"tweets":      tweets,
+		"next_cursor": nextCursor,
+	})
+}
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"GO-X/utils" // Import the utils package to read the public signing keys
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// GetJWKS handles GET /.well-known/jwks.json
+// It publishes the public keys used to sign GO-X tokens, so other services can verify them
+// without sharing a secret. Keys retired by a rotation stay listed until they are removed from the configuration.
+func GetJWKS(c *fiber.Ctx) error {
+	// Let clients cache the keys for a while; they are only re-fetched when an unknown "kid" shows up
+	c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"keys": utils.JWKS(),
+	})
+}
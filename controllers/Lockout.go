@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"GO-X/models" // Import the models package to record failed logins
+	"log"         // Import the log package to print error messages
+	"strconv"     // To write the Retry-After header
+	"strings"     // To normalize usernames
+	"time"        // To compute how long logins are blocked
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// loginPolicies decides how failed logins are throttled, for each scope
+// Accounts are locked quickly; IPs get more room, since many users may share one behind a NAT.
+var loginPolicies = map[string]models.LockoutPolicy{
+	models.LoginScopeUser: {
+		FreeAttempts:     3,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+		ResetAfter:       24 * time.Hour,
+	},
+	models.LoginScopeIP: {
+		FreeAttempts:     20,
+		BaseDelay:        time.Second,
+		MaxDelay:         time.Minute,
+		LockoutThreshold: 100,
+		LockoutDuration:  15 * time.Minute,
+		ResetAfter:       time.Hour,
+	},
+}
+
+// SetLoginLockoutPolicy changes how failed logins are throttled for a scope (models.LoginScopeUser or models.LoginScopeIP)
+func SetLoginLockoutPolicy(scope string, policy models.LockoutPolicy) {
+	loginPolicies[scope] = policy
+}
+
+// LoginLockoutResetAfter returns how long failed logins are remembered, in the scope that remembers them the longest
+// Older records can be purged
+func LoginLockoutResetAfter() time.Duration {
+	var longest time.Duration
+	for _, policy := range loginPolicies {
+		if policy.ResetAfter > longest {
+			longest = policy.ResetAfter
+		}
+	}
+	return longest
+}
+
+// loginSubjects returns what failed logins are counted against: the account and the client IP
+// Usernames are compared case-insensitively by the database, so they are lower-cased here too
+func loginSubjects(c *fiber.Ctx, username string) map[string]string {
+	return map[string]string{
+		models.LoginScopeUser: strings.ToLower(username),
+		models.LoginScopeIP:   c.IP(),
+	}
+}
+
+// reserveLoginAttempt makes sure neither the account nor the client IP is blocked after failed logins, and counts
+// the attempt against both before the credentials are checked. Counting first means parallel attempts can't all
+// get through before the first failure is recorded; an attempt that succeeds is taken back with releaseLoginAttempt.
+// Unknown usernames are throttled like existing ones, so the response never reveals whether an account exists.
+// When logins are blocked it writes the error response and returns false.
+func reserveLoginAttempt(c *fiber.Ctx, username string) (bool, error) {
+	throttles, retryAfter, err := models.ReserveLoginAttempt(db, loginSubjects(c, username), loginPolicies, time.Now())
+	if err != nil {
+		log.Println("Error checking failed logins:", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if retryAfter == 0 {
+		for _, throttle := range throttles {
+			if throttle.Failures == loginPolicies[throttle.Scope].LockoutThreshold {
+				log.Printf("Login locked for %s %q after %d failed attempts", throttle.Scope, throttle.Subject, throttle.Failures)
+			}
+		}
+		return true, nil
+	}
+
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	c.Set("Retry-After", strconv.Itoa(seconds))
+	return false, c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"status":      "error",
+		"message":     "Too many failed login attempts, please try again later",
+		"retry_after": seconds,
+	})
+}
+
+// releaseLoginAttempt takes back the attempt counted by reserveLoginAttempt once the credentials proved right
+// The failures of the account are forgotten. The IP counter only loses this attempt: one valid account
+// must not let an attacker reset it. Errors are only logged, the login went through anyway.
+func releaseLoginAttempt(c *fiber.Ctx, username string) {
+	if _, err := models.ClearLoginFailures(db, models.LoginScopeUser, strings.ToLower(username)); err != nil {
+		log.Println("Error clearing failed logins:", err)
+	}
+	if err := models.ReleaseLoginAttempt(db, models.LoginScopeIP, c.IP(), loginPolicies[models.LoginScopeIP]); err != nil {
+		log.Println("Error releasing login attempt:", err)
+	}
+}
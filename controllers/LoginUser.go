@@ -43,40 +43,95 @@ func LoginUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Refuse to check the password while the account or the client is locked out after failed attempts;
+	// otherwise the attempt counts as a failure until the password proves right
+	if ok, err := reserveLoginAttempt(c, loginRequest.Username); !ok {
+		return err
+	}
+
 	// Retrieve the user from the database
 	user, err := models.GetUserByUsernameAndPassword(db, loginRequest.Username, loginRequest.Password)
-	if err != nil {
+	if err != nil && err != models.ErrPasswordMismatch {
 		log.Println("Error fetching user:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Failed to authenticate user",
-			"Error":   err.Error(),
 		})
 	}
 
-	// Check if user exists and password matches
-	// if user == nil || !models.CheckPasswordHash(loginRequest.Password, user.Password) {
-	// 	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-	// 		"status":  "error",
-	// 		"message": "Invalid credentials",
-	// 	})
-	// }
+	// Unknown usernames and wrong passwords get the same answer, so accounts can't be enumerated
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid username or password",
+		})
+	}
+	releaseLoginAttempt(c, loginRequest.Username)
+
+	return completeLogin(c, user, "Login successful")
+}
+
+// completeLogin answers a request whose password was just checked
+// Users with two-factor authentication get a short-lived mfa_pending token instead of a session;
+// they exchange it for real tokens at POST /auth/mfa/verify together with their code.
+// Everyone else gets an access token and a refresh token right away.
+// Suspended users get neither.
+func completeLogin(c *fiber.Ctx, user *models.User, message string) error {
+	// Suspended accounts can't log in; the password was right, so telling them why leaks nothing
+	if user.Suspended() {
+		return accountSuspended(c)
+	}
+
+	mfa, err := models.GetUserMFA(db, user.ID)
+	if err != nil {
+		log.Println("Error fetching 2FA settings:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to authenticate user",
+		})
+	}
+	if mfa.Enabled() {
+		mfaToken, err := utils.GenerateMFAPendingJWT(user.Username)
+		if err != nil {
+			log.Println("Error generating MFA token:", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to generate token",
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":       "success",
+			"message":      message + ", two-factor authentication required",
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"expires_in":   int(utils.MFAPendingTokenTTL.Seconds()),
+		})
+	}
 
-	// Generate JWT token
-	token, err := utils.GenerateJWT(user.Username)
+	// Generate a short-lived JWT and a refresh token
+	tokens, err := issueTokens(user)
 	if err != nil {
-		log.Println("Error generating JWT:", err)
+		log.Println("Error generating tokens:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Failed to generate token",
-			"Error":   err.Error(),
 		})
 	}
 
-	// Return the token to the user
+	// Return the tokens to the user
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"status":  "success",
-		"message": "Login successful",
-		"token":   token,
+		"status":        "success",
+		"message":       message,
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
+	})
+}
+
+// accountSuspended writes the response sent when a suspended user tries to get a session
+func accountSuspended(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"status":  "error",
+		"message": "Your account is suspended",
 	})
 }
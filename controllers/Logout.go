@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"GO-X/models" // Import the models package to revoke refresh tokens
+	"GO-X/utils"  // Import the utils package to revoke access tokens
+	"log"         // Import the log package to print error messages
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// LogoutRequest struct defines the optional data sent on logout
+// When the refresh token is included, it is revoked together with the access token
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LogoutUser handles POST /auth/logout
+// It revokes the access token used for the request and, if provided, the matching refresh token
+func LogoutUser(c *fiber.Ctx) error {
+	// The body is optional, so only parse it when there is one
+	var logoutRequest LogoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&logoutRequest); err != nil {
+			log.Println("BodyParser error:", err)
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Review your input",
+				"errors":  err.Error(),
+			})
+		}
+	}
+
+	// Revoke the access token itself
+	if err := utils.RevokeToken(getClaims(c)); err != nil {
+		log.Println("Error revoking token:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to log out",
+		})
+	}
+
+	// Revoke the refresh token family, so the session can't be renewed
+	if logoutRequest.RefreshToken != "" {
+		if err := models.RevokeRefreshTokenFamily(db, utils.HashToken(logoutRequest.RefreshToken)); err != nil {
+			log.Println("Error revoking refresh token:", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to log out",
+			})
+		}
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Logged out successfully",
+	})
+}
+
+// LogoutAllSessions handles POST /auth/logout-all
+// It revokes every access token and every refresh token of the authenticated user ("log out everywhere")
+func LogoutAllSessions(c *fiber.Ctx) error {
+	username := getClaimsUsername(c)
+
+	user, err := models.GetUserByUsername(db, username)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+	}
+
+	if err := revokeAllSessions(user); err != nil {
+		log.Println("Error revoking sessions:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to log out",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Logged out from all sessions",
+	})
+}
+
+// revokeAllSessions revokes every access token and refresh token of the user
+func revokeAllSessions(user *models.User) error {
+	if err := utils.RevokeAllTokens(user.Username); err != nil {
+		return err
+	}
+	return models.RevokeRefreshTokensForUser(db, user.ID)
+}
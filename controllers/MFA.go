@@ -0,0 +1,416 @@
+package controllers
+
+import (
+	"GO-X/models" // Import the models package to store the 2FA settings
+	"GO-X/utils"  // Import the utils package for TOTP codes and tokens
+	"log"         // Import the log package to print error messages
+	"time"        // TOTP codes depend on the current time
+
+	"github.com/go-playground/validator/v10" // Import Go validator package for input validation
+	"github.com/gofiber/fiber/v2"            // Import the Fiber web framework to handle HTTP requests
+)
+
+// recoveryCodeCount is how many recovery codes are issued at once
+const recoveryCodeCount = 10
+
+// MFACodeRequest struct defines the data sent to confirm 2FA
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// MFAVerifyRequest struct defines the data sent to finish a login with 2FA
+// Either the code of the authenticator app or one of the recovery codes is required
+type MFAVerifyRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+// MFARecoveryCodesRequest struct defines the data sent to regenerate the recovery codes
+// Both the password and a code of the authenticator app are asked for, so a stolen session alone can't do it
+type MFARecoveryCodesRequest struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// MFADisableRequest struct defines the data sent to turn 2FA off
+// Both the password and a code are asked for, so a stolen session alone can't do it
+type MFADisableRequest struct {
+	Password     string `json:"password" validate:"required"`
+	Code         string `json:"code" validate:"required_without=RecoveryCode,omitempty,len=6,numeric"`
+	RecoveryCode string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+// EnrollMFA handles POST /auth/mfa/enroll
+// It generates a TOTP secret for the authenticated user. 2FA is only turned on once a first code
+// is confirmed through ConfirmMFA, so an abandoned enrollment never locks anyone out.
+func EnrollMFA(c *fiber.Ctx) error {
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	secret, uri, err := utils.NewTOTPSecret(user.Username)
+	if err != nil {
+		log.Println("Error generating TOTP secret:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	if err := models.StartMFAEnrollment(db, user.ID, secret); err != nil {
+		if err == models.ErrMFAAlreadyEnabled {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Two-factor authentication is already enabled",
+			})
+		}
+		log.Println("Error storing TOTP secret:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"message":     "Scan the otpauth URI with an authenticator app, then confirm the first code",
+		"secret":      secret,
+		"otpauth_uri": uri,
+	})
+}
+
+// ConfirmMFA handles POST /auth/mfa/confirm
+// The first valid code turns 2FA on; the recovery codes are returned only in this response
+func ConfirmMFA(c *fiber.Ctx) error {
+	var request MFACodeRequest
+	if ok, err := parseMFARequest(c, &request); !ok {
+		return err
+	}
+
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	mfa, err := models.GetUserMFA(db, user.ID)
+	if err != nil {
+		log.Println("Error fetching 2FA settings:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if mfa == nil || mfa.Enabled() {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "No two-factor enrollment in progress",
+		})
+	}
+
+	// Wrong codes are throttled like failed logins, so a stolen session can't guess its way through
+	if ok, err := reserveLoginAttempt(c, user.Username); !ok {
+		return err
+	}
+	step, ok := utils.ValidateTOTP(mfa.Secret, request.Code, time.Now())
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid code",
+		})
+	}
+	releaseLoginAttempt(c, user.Username)
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		log.Println("Error generating recovery codes:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	confirmed, err := models.ConfirmMFA(db, user.ID, step, hashes)
+	if err != nil {
+		log.Println("Error confirming 2FA:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if !confirmed {
+		// Another request confirmed it in the meantime
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "No two-factor enrollment in progress",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":         "success",
+		"message":        "Two-factor authentication enabled. Store the recovery codes somewhere safe, they won't be shown again",
+		"recovery_codes": codes,
+	})
+}
+
+// VerifyMFA handles POST /auth/mfa/verify
+// It finishes a login started with a password: the mfa_token returned by LoginUser and a code from the
+// authenticator app (or a recovery code) are exchanged for an access token and a refresh token.
+// Wrong codes count as failed logins, so they are throttled like wrong passwords.
+func VerifyMFA(c *fiber.Ctx) error {
+	var request MFAVerifyRequest
+	if ok, err := parseMFARequest(c, &request); !ok {
+		return err
+	}
+
+	// The token must be an unused mfa_pending token
+	claims, err := utils.ValidateJWT(request.MFAToken)
+	if err != nil || utils.TokenUse(claims) != utils.TokenUseMFAPending {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired MFA token, log in again",
+		})
+	}
+	revoked, err := utils.IsTokenRevoked(claims)
+	if err != nil {
+		log.Println("Error checking token revocation:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if revoked {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired MFA token, log in again",
+		})
+	}
+	username, _ := claims["username"].(string)
+
+	if ok, err := reserveLoginAttempt(c, username); !ok {
+		return err
+	}
+
+	user, err := models.GetUserByUsername(db, username)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired MFA token, log in again",
+		})
+	}
+
+	valid, err := checkMFACode(user.ID, request.Code, request.RecoveryCode)
+	if err != nil {
+		log.Println("Error checking 2FA code:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if !valid {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid code",
+		})
+	}
+	releaseLoginAttempt(c, username)
+
+	// The account may have been suspended since the password step
+	if user.Suspended() {
+		return accountSuspended(c)
+	}
+
+	// The pending token has done its job, it must not be exchanged twice
+	if err := utils.RevokeToken(claims); err != nil {
+		log.Println("Error revoking MFA token:", err)
+	}
+
+	tokens, err := issueTokens(user)
+	if err != nil {
+		log.Println("Error generating tokens:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to generate token",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":        "success",
+		"message":       "Login successful",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
+	})
+}
+
+// RegenerateRecoveryCodes handles POST /auth/mfa/recovery-codes
+// It replaces every recovery code of the authenticated user; the password and a current TOTP code are required
+func RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var request MFARecoveryCodesRequest
+	if ok, err := parseMFARequest(c, &request); !ok {
+		return err
+	}
+
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	if ok, err := checkPasswordAndMFACode(c, user, request.Password, request.Code, ""); !ok {
+		return err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err == nil {
+		err = models.RegenerateRecoveryCodes(db, user.ID, hashes)
+	}
+	if err != nil {
+		log.Println("Error regenerating recovery codes:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":         "success",
+		"message":        "New recovery codes generated, the old ones no longer work",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableMFA handles POST /auth/mfa/disable
+// It turns 2FA off after checking both the password and a code, throttled like a login
+func DisableMFA(c *fiber.Ctx) error {
+	var request MFADisableRequest
+	if ok, err := parseMFARequest(c, &request); !ok {
+		return err
+	}
+
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	if ok, err := checkPasswordAndMFACode(c, user, request.Password, request.Code, request.RecoveryCode); !ok {
+		return err
+	}
+
+	if err := models.DisableMFA(db, user.ID); err != nil {
+		log.Println("Error disabling 2FA:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// checkPasswordAndMFACode checks the password of a signed-in user and a code of their 2FA before a sensitive change
+// Both checks go through the login throttle, as a wrong password or code counts as a failed login.
+// When either is wrong it writes the error response and returns false.
+func checkPasswordAndMFACode(c *fiber.Ctx, user *models.User, password string, code string, recoveryCode string) (bool, error) {
+	if ok, err := reserveLoginAttempt(c, user.Username); !ok {
+		return false, err
+	}
+
+	// Check the password again, with the same bcrypt comparison as the login
+	confirmed, err := models.GetUserByUsernameAndPassword(db, user.Username, password)
+	if err != nil && err != models.ErrPasswordMismatch {
+		log.Println("Error checking password:", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	valid := false
+	if confirmed != nil {
+		valid, err = checkMFACode(user.ID, code, recoveryCode)
+		if err != nil {
+			log.Println("Error checking 2FA code:", err)
+			return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Internal server error",
+			})
+		}
+	}
+	if !valid {
+		return false, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid password or code",
+		})
+	}
+
+	releaseLoginAttempt(c, user.Username)
+	return true, nil
+}
+
+// checkMFACode checks a TOTP code, or else a recovery code, against the confirmed 2FA of a user
+// A TOTP code is accepted only once, and a recovery code is used up by a successful check.
+// It returns false when the user has no confirmed 2FA.
+func checkMFACode(userID int, code string, recoveryCode string) (bool, error) {
+	mfa, err := models.GetUserMFA(db, userID)
+	if err != nil || !mfa.Enabled() {
+		return false, err
+	}
+
+	if code != "" {
+		step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now())
+		if !ok {
+			return false, nil
+		}
+		return models.UseMFAStep(db, userID, step)
+	}
+	if recoveryCode != "" {
+		return models.UseRecoveryCode(db, userID, utils.HashRecoveryCode(recoveryCode))
+	}
+	return false, nil
+}
+
+// newRecoveryCodes generates a set of recovery codes and their hashes
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = utils.HashRecoveryCode(code)
+	}
+	return codes, hashes, nil
+}
+
+// parseMFARequest parses and validates the body of a 2FA request into request
+// When the body is invalid it writes the error response and returns false
+func parseMFARequest(c *fiber.Ctx, request interface{}) (bool, error) {
+	if err := c.BodyParser(request); err != nil {
+		log.Println("BodyParser error:", err)
+		return false, c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Review your input",
+			"errors":  err.Error(),
+		})
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(request); err != nil {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+		})
+	}
+	return true, nil
+}
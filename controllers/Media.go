@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"GO-X/models"  // Import the models package to record uploaded media
+	"GO-X/storage" // Import the storage package where the files are kept
+	"GO-X/utils"   // Import the utils package to generate random storage keys
+	"image"        // To read the dimensions of uploaded images
+	_ "image/gif"  // Register the GIF decoder for image.DecodeConfig
+	_ "image/jpeg" // Register the JPEG decoder for image.DecodeConfig
+	_ "image/png"  // Register the PNG decoder for image.DecodeConfig
+	"io"           // To rewind the uploaded file after sniffing it
+	"log"          // Import the log package to print error messages
+	"net/http"     // To sniff the content type of uploads
+	"time"         // Unattached media expire
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+const (
+	// MaxMediaSize is the largest file accepted by POST /media, in bytes
+	// The body limit of the Fiber app must leave room for it (see main.go)
+	MaxMediaSize = 5 * 1024 * 1024
+	// maxMediaDimension is the largest width or height accepted, in pixels
+	maxMediaDimension = 8192
+)
+
+// mediaTypes lists the accepted content types and the file extension used to store them
+// The type is sniffed from the first bytes of the file; the name and type sent by the client are ignored
+var mediaTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+var mediaStore storage.Store // Declare a variable to store the media storage backend
+
+// mediaOrphanTTL is how long uploaded media wait to be attached to a tweet before they are purged
+var mediaOrphanTTL = 24 * time.Hour
+
+// SetMediaStore sets the storage backend used for uploaded media
+// This function is called from the main app, like SetDB
+func SetMediaStore(store storage.Store) {
+	mediaStore = store
+}
+
+// SetMediaOrphanTTL sets how long uploaded media can wait to be attached to a tweet
+// Older media are refused, since the purge of orphaned media may be deleting them
+func SetMediaOrphanTTL(ttl time.Duration) {
+	mediaOrphanTTL = ttl
+}
+
+// UploadMedia handles POST /media
+// It accepts one image in the "file" field of a multipart form. The returned ID can then be sent
+// in "media_ids" when posting a tweet; media that are never attached are deleted after a while.
+func UploadMedia(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Send the image in the \"file\" field of a multipart form",
+		})
+	}
+	if fileHeader.Size > MaxMediaSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"status":  "error",
+			"message": "File is too large (5 MB maximum)",
+		})
+	}
+	if fileHeader.Size == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "File is empty",
+		})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		log.Println("Error opening upload:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to upload media",
+		})
+	}
+	defer file.Close()
+
+	// Sniff the real type of the file from its first bytes
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(file, head)
+	contentType := http.DetectContentType(head[:n])
+	extension, ok := mediaTypes[contentType]
+	if !ok {
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Only JPEG, PNG and GIF images are accepted",
+		})
+	}
+
+	// Read the dimensions from the image header, without decoding the whole image
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		log.Println("Error rewinding upload:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to upload media",
+		})
+	}
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "The image is corrupted",
+		})
+	}
+	if config.Width < 1 || config.Height < 1 || config.Width > maxMediaDimension || config.Height > maxMediaDimension {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Images must be at most 8192 pixels wide and high",
+		})
+	}
+
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	// Store the file under a random name, so names can't be guessed or collide
+	name, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		log.Println("Error generating media key:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to upload media",
+		})
+	}
+	key := "media/" + name + extension
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		log.Println("Error rewinding upload:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to upload media",
+		})
+	}
+	if err := mediaStore.Put(c.UserContext(), key, contentType, file, fileHeader.Size); err != nil {
+		log.Println("Error storing media:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to upload media",
+		})
+	}
+
+	media := models.Media{
+		UserID:      user.ID,
+		StorageKey:  key,
+		ContentType: contentType,
+		Size:        fileHeader.Size,
+		Width:       config.Width,
+		Height:      config.Height,
+	}
+	if err := models.CreateMedia(db, &media); err != nil {
+		log.Println("Error saving media:", err)
+		// Don't leave a file nothing points to
+		if err := mediaStore.Delete(c.UserContext(), key); err != nil {
+			log.Println("Error deleting stored media:", err)
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to upload media",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Media uploaded successfully",
+		"media":   media,
+	})
+}
+
+// GetMediaFile handles GET /media/:id/file
+// It serves the file of a media attached to a tweet; it is public, so it can be used as an <img> source
+func GetMediaFile(c *fiber.Ctx) error {
+	mediaID, ok := parseIDParam(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid media ID",
+		})
+	}
+
+	media, err := models.GetMediaByID(db, mediaID)
+	if err != nil {
+		log.Println("Error fetching media:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	// Media that aren't attached to a tweet aren't published yet
+	if media == nil || media.TweetID == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Media not found",
+		})
+	}
+
+	file, err := mediaStore.Get(c.UserContext(), media.StorageKey)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Media not found",
+			})
+		}
+		log.Println("Error reading stored media:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	// Files never change once uploaded, so clients and proxies can keep them
+	c.Set(fiber.HeaderContentType, media.ContentType)
+	c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	return c.SendStream(file, int(media.Size)) // The file is closed once it has been sent
+}
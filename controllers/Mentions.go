@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"GO-X/models"   // Import the models package to list mentions and check blocks
+	"GO-X/realtime" // Import the realtime package to tell mentioned users right away
+	"GO-X/utils"    // Import the utils package to build pagination cursors
+	"log"           // Import the log package to print error messages
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// GetMyMentions handles GET /users/me/mentions
+// It lists the tweets mentioning the authenticated user, most recent first, with cursor pagination.
+// Tweets of users they block are left out.
+func GetMyMentions(c *fiber.Ctx) error {
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	var cursor idCursor
+	if !parseCursor(c, &cursor) {
+		return invalidCursor(c)
+	}
+	limit := parseLimit(c)
+
+	tweets, err := models.GetMentions(db, user.ID, cursor.ID, limit)
+	if err != nil {
+		log.Println("Error listing mentions:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if shown, err := hideBlockedQuotes(c, user.ID, tweets...); !shown {
+		return err
+	}
+
+	// A full page means there may be more: hand out a cursor pointing after the last tweet
+	var nextCursor string
+	if len(tweets) == limit {
+		nextCursor = utils.EncodeCursor(idCursor{ID: tweets[len(tweets)-1].ID})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"tweets":      tweets,
+		"next_cursor": nextCursor,
+	})
+}
+
+// notifyMentions tells the users mentioned in a tweet about it, with a notification and a real-time event
+// The author, the users in skip (already told about the tweet another way, e.g. as the author of the tweet
+// being answered) and the users who block the author are not notified.
+func notifyMentions(tweet *models.Tweet, skip ...int) {
+	skipped := map[int]bool{tweet.UserID: true}
+	for _, id := range skip {
+		skipped[id] = true
+	}
+	userIDs := []int{}
+	for _, id := range tweet.MentionedUserIDs() {
+		if !skipped[id] {
+			userIDs = append(userIDs, id)
+		}
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	blockers, err := models.GetBlockersAmong(db, tweet.UserID, userIDs)
+	if err != nil {
+		log.Println("Error checking blocks before notifying mentions:", err)
+		return
+	}
+	author := models.UserRef{ID: tweet.UserID, Username: tweet.Username}
+	for _, id := range userIDs {
+		if blockers[id] {
+			continue
+		}
+		recordNotification(id, tweet.UserID, models.NotificationMention, tweet.ID)
+		publishTweetEvent(id, realtime.EventMention, fiber.Map{
+			"user":  author,
+			"tweet": tweet,
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"GO-X/models" // Import the models package to read and update notifications
+	"GO-X/utils"  // Import the utils package to build pagination cursors
+	"log"         // Import the log package to print error messages
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// recordNotification adds a notification to the inbox of a user, in the background
+// Users are not notified about their own actions. tweetID is 0 for notifications without a tweet (follows).
+// Errors are only logged: a missed notification must not fail the action that caused it
+func recordNotification(userID int, actorID int, kind string, tweetID int) {
+	if userID == actorID {
+		return
+	}
+	go func() {
+		if err := models.RecordNotification(db, userID, actorID, kind, tweetID); err != nil {
+			log.Println("Error recording notification ("+kind+"):", err)
+		}
+	}()
+}
+
+// GetNotifications handles GET /notifications
+// It returns the caller's notifications, most recently updated first, with the unread count.
+// ?cursor= is the opaque next_cursor of the previous page, and ?unread=true skips the notifications already read.
+func GetNotifications(c *fiber.Ctx) error {
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	// Read where the previous page stopped, if any
+	var after *models.NotificationPosition
+	if c.Query("cursor") != "" {
+		after = &models.NotificationPosition{}
+		if !parseCursor(c, after) {
+			return invalidCursor(c)
+		}
+	}
+	limit := parseLimit(c)
+
+	notifications, err := models.GetNotifications(db, user.ID, after, c.QueryBool("unread"), limit)
+	if err != nil {
+		log.Println("Error listing notifications:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	shownTweets := make([]*models.Tweet, len(notifications))
+	for i := range notifications {
+		shownTweets[i] = notifications[i].Tweet
+	}
+	if shown, err := hideBlockedQuotes(c, user.ID, shownTweets...); !shown {
+		return err
+	}
+
+	unread, err := models.CountUnreadNotifications(db, user.ID)
+	if err != nil {
+		log.Println("Error counting unread notifications:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	// Only send a cursor when the page is full, otherwise there is nothing left to read
+	var nextCursor string
+	if len(notifications) == limit {
+		nextCursor = utils.EncodeCursor(notifications[len(notifications)-1].Position())
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":        "success",
+		"notifications": notifications,
+		"unread_count":  unread,
+		"next_cursor":   nextCursor,
+	})
+}
+
+// GetUnreadNotificationCount handles GET /notifications/unread-count
+// It is cheap enough to be polled, e.g. to show a badge
+func GetUnreadNotificationCount(c *fiber.Ctx) error {
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	unread, err := models.CountUnreadNotifications(db, user.ID)
+	if err != nil {
+		log.Println("Error counting unread notifications:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":       "success",
+		"unread_count": unread,
+	})
+}
+
+// MarkNotificationRead handles POST /notifications/:id/read
+// Marking a notification that is already read is not an error
+func MarkNotificationRead(c *fiber.Ctx) error {
+	notificationID, ok := parseIDParam(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid notification ID",
+		})
+	}
+
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	found, err := models.MarkNotificationRead(db, user.ID, notificationID)
+	if err != nil {
+		log.Println("Error marking notification as read:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	// Someone else's notification is reported as missing, so IDs can't be probed
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Notification not found",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Notification marked as read",
+	})
+}
+
+// MarkAllNotificationsRead handles POST /notifications/read-all
+func MarkAllNotificationsRead(c *fiber.Ctx) error {
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	updated, err := models.MarkAllNotificationsRead(db, user.ID)
+	if err != nil {
+		log.Println("Error marking notifications as read:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "All notifications marked as read",
+		"updated": updated,
+	})
+}
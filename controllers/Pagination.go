@@ -0,0 +1,47 @@
+package controllers
+
+import (
+	"GO-X/utils" // Import the utils package to encode and decode cursors
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to read query parameters
+)
+
+const (
+	defaultPageSize = 20  // Number of items returned when ?limit= is missing
+	maxPageSize     = 100 // Largest ?limit= a client may ask for
+)
+
+// parseLimit reads the ?limit= query parameter and keeps it between 1 and maxPageSize
+func parseLimit(c *fiber.Ctx) int {
+	limit := c.QueryInt("limit", defaultPageSize)
+	if limit < 1 {
+		return defaultPageSize
+	}
+	if limit > maxPageSize {
+		return maxPageSize
+	}
+	return limit
+}
+
+// parseCursor reads the ?cursor= query parameter into position
+// It returns false when a cursor was sent but can't be decoded; a missing cursor leaves position untouched
+func parseCursor(c *fiber.Ctx, position interface{}) bool {
+	cursor := c.Query("cursor")
+	if cursor == "" {
+		return true
+	}
+	return utils.DecodeCursor(cursor, position) == nil
+}
+
+// idCursor is the position used by lists ordered by a row ID, newest first
+type idCursor struct {
+	ID int `json:"id"`
+}
+
+// invalidCursor writes the response sent when ?cursor= can't be decoded
+func invalidCursor(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"status":  "error",
+		"message": "Invalid cursor",
+	})
+}
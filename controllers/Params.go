@@ -0,0 +1,15 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to read route parameters
+)
+
+// parseIDParam reads a positive numeric route parameter such as ":id"
+// The second return value is false when the parameter is missing or not a positive number
+func parseIDParam(c *fiber.Ctx, name string) (int, bool) {
+	id, err := c.ParamsInt(name)
+	if err != nil || id <= 0 {
+		return 0, false
+	}
+	return id, true
+}
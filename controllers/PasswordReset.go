@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"GO-X/mailer" // Import the mailer package to send the reset token by email
+	"GO-X/models" // Import the models package to read and update users and reset tokens
+	"GO-X/utils"  // Import the utils package to generate and hash tokens
+	"log"         // Import the log package to print error messages
+	"time"        // To compute the expiration time of reset tokens
+
+	"github.com/go-playground/validator/v10" // Import Go validator package for input validation
+	"github.com/gofiber/fiber/v2"            // Import the Fiber web framework to handle HTTP requests
+)
+
+// passwordResetTTL is how long a password reset token stays valid after it was emailed
+const passwordResetTTL = time.Hour
+
+var mail mailer.Sender // Declare a variable to store the email sender
+
+// SetMailer sets the email sender used by the controllers package
+// This function is called from the main app, so tests can pass a memory-backed sender instead
+func SetMailer(sender mailer.Sender) {
+	mail = sender
+}
+
+// ForgotPasswordRequest struct defines the expected data to request a password reset
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"` // The email address of the account
+}
+
+// ResetPasswordRequest struct defines the expected data to reset a password
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`                 // The token received by email
+	Password string `json:"password" validate:"required,min=6,max=50"` // The new password, same rules as registration
+}
+
+// ForgotPassword handles POST /auth/forgot-password
+// It emails a single-use reset token to the user. The response is always the same,
+// whether or not the email belongs to an account, so it can't be used to discover registered emails.
+func ForgotPassword(c *fiber.Ctx) error {
+	// Check if the request body is empty
+	if c.Body() == nil || len(c.Body()) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Request body cannot be empty",
+		})
+	}
+
+	// Parse the incoming request body into the ForgotPasswordRequest struct
+	var forgotRequest ForgotPasswordRequest
+	if err := c.BodyParser(&forgotRequest); err != nil {
+		log.Println("BodyParser error:", err)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Review your input",
+			"errors":  err.Error(),
+		})
+	}
+
+	// Validate the parsed input using the Go validator package
+	validate := validator.New()
+	if err := validate.Struct(forgotRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+		})
+	}
+
+	// The response we send back no matter what happens below
+	genericResponse := fiber.Map{
+		"status":  "success",
+		"message": "If an account exists for this email, a password reset link has been sent",
+	}
+
+	// Look up the user by email
+	user, err := models.GetUserByEmail(db, sanitizeInput(forgotRequest.Email))
+	if err != nil {
+		log.Println("Error fetching user by email:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		// Don't reveal that the email is unknown
+		return c.Status(fiber.StatusOK).JSON(genericResponse)
+	}
+
+	// Generate a random token; only its hash is stored in the database
+	token, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		log.Println("Error generating reset token:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to process request",
+		})
+	}
+
+	if err := models.CreatePasswordReset(db, user.ID, utils.HashToken(token), time.Now().Add(passwordResetTTL)); err != nil {
+		log.Println("Error storing reset token:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to process request",
+		})
+	}
+
+	// Send the token to the user
+	err = mail.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: "Hi " + user.Username + ",\n\n" +
+			"Use the following token to reset your password. It expires in 1 hour.\n\n" +
+			token + "\n\n" +
+			"If you didn't ask for a password reset, you can ignore this email.",
+	})
+	if err != nil {
+		// Log the failure but keep the response identical, so it doesn't leak that the account exists
+		log.Println("Error sending reset email:", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(genericResponse)
+}
+
+// ResetPassword handles POST /auth/reset-password
+// It checks the emailed token and, if it's valid, replaces the user's password
+func ResetPassword(c *fiber.Ctx) error {
+	// Check if the request body is empty
+	if c.Body() == nil || len(c.Body()) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Request body cannot be empty",
+		})
+	}
+
+	// Parse the incoming request body into the ResetPasswordRequest struct
+	var resetRequest ResetPasswordRequest
+	if err := c.BodyParser(&resetRequest); err != nil {
+		log.Println("BodyParser error:", err)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Review your input",
+			"errors":  err.Error(),
+		})
+	}
+
+	// Validate the parsed input using the Go validator package
+	validate := validator.New()
+	if err := validate.Struct(resetRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+		})
+	}
+
+	// Find the token and remove it, so it can't be used a second time
+	reset, err := models.ConsumePasswordReset(db, utils.HashToken(resetRequest.Token))
+	if err != nil {
+		log.Println("Error consuming reset token:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if reset == nil || reset.Expired() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired reset token",
+		})
+	}
+
+	// Hash the new password before storing it in the database
+	hashedPassword, err := models.HashPassword(resetRequest.Password)
+	if err != nil {
+		log.Println("Error hashing password:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to reset password",
+		})
+	}
+
+	if err := models.UpdatePassword(db, reset.UserID, hashedPassword); err != nil {
+		log.Println("Error updating password:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to reset password",
+		})
+	}
+
+	// Invalidate any other reset token the user may still have
+	if err := models.DeletePasswordResetsForUser(db, reset.UserID); err != nil {
+		log.Println("Error deleting reset tokens:", err)
+	}
+
+	// Sessions started with the old password must stop working
+	user, err := models.GetUserByID(db, reset.UserID)
+	if err == nil && user != nil {
+		err = revokeAllSessions(user)
+	}
+	if err != nil {
+		log.Println("Error revoking sessions:", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Password has been reset successfully",
+	})
+}
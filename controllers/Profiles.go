@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"GO-X/models" // Import the models package to read and update profiles
+	"log"         // Import the log package to print error messages
+	"strings"     // To trim whitespace around the profile fields
+
+	"github.com/go-playground/validator/v10" // Import Go validator package for input validation
+	"github.com/gofiber/fiber/v2"            // Import the Fiber web framework to handle HTTP requests
+)
+
+// ProfileRequest struct defines the expected data to edit a profile
+// Every field is optional: missing fields are left as they are, and an empty string clears a field.
+// URLs must use http or https, so a profile can't carry e.g. a javascript: link.
+type ProfileRequest struct {
+	DisplayName        *string `json:"display_name" validate:"omitempty,max=50"`
+	Bio                *string `json:"bio" validate:"omitempty,max=160"`
+	Location           *string `json:"location" validate:"omitempty,max=30"`
+	Website            *string `json:"website" validate:"omitempty,max=100,len=0|http_url"`
+	AvatarURL          *string `json:"avatar_url" validate:"omitempty,max=255,len=0|http_url"`
+	BannerURL          *string `json:"banner_url" validate:"omitempty,max=255,len=0|http_url"`
+	HideProfileDetails *bool   `json:"hide_profile_details"` // Only show the details and follow lists to followers
+}
+
+// GetUserProfile handles GET /users/:username
+// When the owner hides them, the profile details are only shown to the owner and their followers
+func GetUserProfile(c *fiber.Ctx) error {
+	viewer, err := loadCurrentUser(c)
+	if viewer == nil {
+		return err
+	}
+
+	profile, err := models.GetUserProfileByUsername(db, c.Params("username"))
+	if err != nil {
+		log.Println("Error fetching profile:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if profile == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "User not found",
+		})
+	}
+
+	following, err := models.IsFollowing(db, viewer.ID, profile.ID)
+	if err != nil {
+		log.Println("Error checking follow:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if profile.HideProfileDetails && viewer.ID != profile.ID && !following {
+		profile.HideDetails()
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":    "success",
+		"user":      profile,
+		"following": following, // Whether the authenticated user follows this user
+	})
+}
+
+// UpdateProfile handles PATCH /users/me
+// It changes the profile fields sent in the body and returns the updated profile
+func UpdateProfile(c *fiber.Ctx) error {
+	// Check if the request body is empty
+	if c.Body() == nil || len(c.Body()) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Request body cannot be empty",
+		})
+	}
+
+	// Parse the incoming request body into the ProfileRequest struct
+	var profileRequest ProfileRequest
+	if err := c.BodyParser(&profileRequest); err != nil {
+		log.Println("BodyParser error:", err)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Review your input",
+			"errors":  err.Error(),
+		})
+	}
+
+	// Trim the text fields before validating, so blank values clear the field
+	for _, field := range []*string{profileRequest.DisplayName, profileRequest.Bio, profileRequest.Location, profileRequest.Website, profileRequest.AvatarURL, profileRequest.BannerURL} {
+		if field != nil {
+			*field = sanitizeInput(strings.TrimSpace(*field))
+		}
+	}
+
+	// Validate the parsed input using the Go validator package
+	validate := validator.New()
+	if err := validate.Struct(profileRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+		})
+	}
+
+	user, err := loadCurrentUser(c)
+	if user == nil {
+		return err
+	}
+
+	update := models.ProfileUpdate{
+		DisplayName:        profileRequest.DisplayName,
+		Bio:                profileRequest.Bio,
+		Location:           profileRequest.Location,
+		Website:            profileRequest.Website,
+		AvatarURL:          profileRequest.AvatarURL,
+		BannerURL:          profileRequest.BannerURL,
+		HideProfileDetails: profileRequest.HideProfileDetails,
+	}
+	if err := models.UpdateUserProfile(db, user.ID, update); err != nil {
+		log.Println("Error updating profile:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to update profile",
+		})
+	}
+
+	profile, err := models.GetUserProfile(db, user.ID)
+	if err != nil {
+		log.Println("Error fetching profile:", err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Profile updated successfully",
+		"user":    profile,
+	})
+}
+
+// canSeeProfileDetails checks that the authenticated user owns or follows ownerID, who hides their profile details
+// When they don't (or the check fails) it writes the error response and returns false
+func canSeeProfileDetails(c *fiber.Ctx, ownerID int) (bool, error) {
+	viewer, err := loadCurrentUser(c)
+	if viewer == nil {
+		return false, err
+	}
+	if viewer.ID == ownerID {
+		return true, nil
+	}
+
+	following, err := models.IsFollowing(db, viewer.ID, ownerID)
+	if err != nil {
+		log.Println("Error checking follow:", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if !following {
+		return false, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "This account only shows its details to followers",
+		})
+	}
+	return true, nil
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"GO-X/models" // Import the models package to list quotes and check blocks
+	"GO-X/utils"  // Import the utils package to build pagination cursors
+	"log"         // Import the log package to print error messages
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+// GetTweetQuotes handles GET /tweets/:id/quotes
+// It lists the tweets quoting the tweet, most recent first, with cursor pagination
+func GetTweetQuotes(c *fiber.Ctx) error {
+	tweet, user, err := loadTweetForUser(c)
+	if tweet == nil {
+		return err
+	}
+
+	var cursor idCursor
+	if !parseCursor(c, &cursor) {
+		return invalidCursor(c)
+	}
+	limit := parseLimit(c)
+
+	quotes, err := models.GetQuotes(db, tweet.ID, cursor.ID, limit)
+	if err != nil {
+		log.Println("Error listing quotes:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if shown, err := hideBlockedQuotes(c, user.ID, quotes...); !shown {
+		return err
+	}
+
+	// A full page means there may be more: hand out a cursor pointing after the last quote
+	var nextCursor string
+	if len(quotes) == limit {
+		nextCursor = utils.EncodeCursor(idCursor{ID: quotes[len(quotes)-1].ID})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"quotes":      quotes,
+		"next_cursor": nextCursor,
+	})
+}
+
+// hideBlockedQuotes replaces with a tombstone the quoted tweets whose author blocks the viewer
+// It must run on every tweet of a response before it is sent. When the check fails it writes
+// the error response and returns false, rather than risk showing a blocked tweet.
+func hideBlockedQuotes(c *fiber.Ctx, viewerID int, tweets ...*models.Tweet) (bool, error) {
+	if err := models.HideBlockedQuotes(db, viewerID, tweets...); err != nil {
+		log.Println("Error checking blocks:", err)
+		return false, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	return true, nil
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"GO-X/models"   // Import the models package to find the followers to notify
+	"GO-X/realtime" // Import the realtime package to push events to connected clients
+	"log"           // Import the log package to print error messages
+	"time"          // To ping idle connections and close them when the token expires
+
+	"github.com/gofiber/contrib/websocket" // Import the Fiber WebSocket middleware
+	"github.com/gofiber/fiber/v2"          // Import the Fiber web framework
+	"github.com/golang-jwt/jwt/v4"         // Import the JWT library for the claims type
+)
+
+// webSocketPingInterval is how often idle connections are pinged, so proxies don't close them
+const webSocketPingInterval = 30 * time.Second
+
+var hub *realtime.Hub // Declare a variable to store the real-time hub
+
+// SetHub sets the hub used to push real-time events to connected clients
+// When it isn't set, no event is pushed
+func SetHub(h *realtime.Hub) {
+	hub = h
+}
+
+// publishEvent pushes an event to one user
+func publishEvent(userID int, eventType string, data interface{}) {
+	if hub == nil {
+		return
+	}
+	hub.Publish(userID, realtime.NewEvent(eventType, data))
+}
+
+// publishTweetEvent pushes an event carrying tweets to one user
+// Every tweet of data (a models.Tweet or *models.Tweet value) is replaced by a copy whose quoted tweet is a
+// tombstone when its author blocks the user, as in the REST responses. When that can't be checked, nothing is sent.
+func publishTweetEvent(userID int, eventType string, data fiber.Map) {
+	if hub == nil {
+		return
+	}
+	shown := make(fiber.Map, len(data))
+	for key, value := range data {
+		var tweet *models.Tweet
+		switch v := value.(type) {
+		case models.Tweet:
+			tweet = &v
+		case *models.Tweet:
+			tweet = v
+		}
+		if tweet != nil {
+			copied := *tweet // The tweet may be shown to other users
+			if err := models.HideBlockedQuotes(db, userID, &copied); err != nil {
+				log.Println("Error checking blocks before publishing:", err)
+				return
+			}
+			value = &copied
+		}
+		shown[key] = value
+	}
+	hub.Publish(userID, realtime.NewEvent(eventType, shown))
+}
+
+// publishTweetToFollowers pushes a new tweet to every follower of its author
+// Followers blocked by the author of a quoted tweet get the tweet with a tombstone instead of the quoted tweet.
+// The followers are loaded in the background, so the request isn't slowed down
+func publishTweetToFollowers(eventType string, tweet *models.Tweet) {
+	if hub == nil {
+		return
+	}
+	go func() {
+		followers, err := models.GetFollowerIDs(db, tweet.UserID)
+		if err != nil {
+			log.Println("Error loading followers to notify:", err)
+			return
+		}
+		if tweet.QuotedTweet == nil || tweet.QuotedTweet.Tweet == nil {
+			hub.PublishMany(followers, realtime.NewEvent(eventType, fiber.Map{"tweet": tweet}))
+			return
+		}
+
+		blocked, err := models.GetBlockedIDs(db, tweet.QuotedTweet.Tweet.UserID)
+		if err != nil {
+			log.Println("Error checking blocks before publishing:", err)
+			return
+		}
+		hidden := *tweet
+		hidden.QuotedTweet = &models.QuotedTweet{ID: tweet.QuotedTweet.ID, Tombstone: models.TombstoneBlocked}
+		shown, tombstoned := []int{}, []int{}
+		for _, id := range followers {
+			if blocked[id] {
+				tombstoned = append(tombstoned, id)
+			} else {
+				shown = append(shown, id)
+			}
+		}
+		hub.PublishMany(shown, realtime.NewEvent(eventType, fiber.Map{"tweet": tweet}))
+		hub.PublishMany(tombstoned, realtime.NewEvent(eventType, fiber.Map{"tweet": &hidden}))
+	}()
+}
+
+// RequireHub answers 503 when real-time events are disabled, before a WebSocket is upgraded
+func RequireHub(c *fiber.Ctx) error {
+	if hub == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Real-time events are disabled",
+		})
+	}
+	return c.Next()
+}
+
+// StreamWebSocket handles the /ws endpoint once the connection is upgraded
+// The connection subscribes to the events of the authenticated user and receives them as JSON messages.
+// It is closed when the token expires, when the client goes away, or when the hub drops a slow client.
+func StreamWebSocket(conn *websocket.Conn) {
+	if hub == nil {
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "real-time events are disabled"))
+		return
+	}
+
+	claims, _ := conn.Locals("claims").(jwt.MapClaims)
+	username, _ := claims["username"].(string)
+
+	user, err := models.GetUserByUsername(db, username)
+	if err != nil || user == nil {
+		log.Println("Error fetching user for WebSocket:", err)
+		conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "unknown user"))
+		return
+	}
+
+	sub := hub.Subscribe(user.ID)
+	defer sub.Close()
+
+	// Read in the background: this answers pings and notices when the client closes the connection
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// The connection must not outlive the token it was opened with
+	expiry := time.NewTimer(time.Until(tokenExpiry(claims)))
+	defer expiry.Stop()
+	ping := time.NewTicker(webSocketPingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case event := <-sub.Events():
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-sub.Done():
+			// The hub gave up on this client because it was too slow
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow, reconnect"))
+			return
+		case <-expiry.C:
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "token expired"))
+			return
+		case <-closed:
+			return
+		}
+	}
+}
+
+// tokenExpiry reads the "exp" claim of a token
+func tokenExpiry(claims jwt.MapClaims) time.Time {
+	if exp, ok := claims["exp"].(float64); ok {
+		return time.Unix(int64(exp), 0)
+	}
+	return time.Now()
+}
@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"GO-X/models" // Import the models package to store and rotate refresh tokens
+	"GO-X/utils"  // Import the utils package to generate JWTs and random tokens
+	"log"         // Import the log package to print error messages
+	"time"        // To compute the expiration time of refresh tokens
+
+	"github.com/go-playground/validator/v10" // Import Go validator package for input validation
+	"github.com/gofiber/fiber/v2"            // Import the Fiber web framework to handle HTTP requests
+)
+
+// refreshTokenTTL is how long a refresh token can be used before the user has to log in again
+const refreshTokenTTL = 30 * 24 * time.Hour
+
+// RefreshRequest struct defines the expected data to refresh an access token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// tokenPair holds an access token and the refresh token that goes with it
+type tokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// issueTokens creates a short-lived access token and starts a new refresh token family for the user
+// It is used after a successful login or registration
+func issueTokens(user *models.User) (*tokenPair, error) {
+	accessToken, err := utils.GenerateJWT(user.Username, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		return nil, err
+	}
+	familyID, err := utils.GenerateSecureToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := models.CreateRefreshToken(db, user.ID, utils.HashToken(refreshToken), familyID, time.Now().Add(refreshTokenTTL)); err != nil {
+		return nil, err
+	}
+	return &tokenPair{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// RefreshToken handles POST /auth/refresh
+// It exchanges a refresh token for a new access token and a new refresh token (rotation-on-use).
+// The old refresh token stops working; presenting it again revokes every token of its family.
+func RefreshToken(c *fiber.Ctx) error {
+	// Check if the request body is empty
+	if c.Body() == nil || len(c.Body()) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Request body cannot be empty",
+		})
+	}
+
+	// Parse the incoming request body into the RefreshRequest struct
+	var refreshRequest RefreshRequest
+	if err := c.BodyParser(&refreshRequest); err != nil {
+		log.Println("BodyParser error:", err)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Review your input",
+			"errors":  err.Error(),
+		})
+	}
+
+	// Validate the parsed input using the Go validator package
+	validate := validator.New()
+	if err := validate.Struct(refreshRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+		})
+	}
+
+	// Generate the replacement refresh token before rotating, so the swap happens in one transaction
+	newRefreshToken, err := utils.GenerateSecureToken(32)
+	if err != nil {
+		log.Println("Error generating refresh token:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to refresh token",
+		})
+	}
+
+	userID, err := models.RotateRefreshToken(db, utils.HashToken(refreshRequest.RefreshToken), utils.HashToken(newRefreshToken), time.Now().Add(refreshTokenTTL))
+	if err != nil {
+		if err == models.ErrRefreshTokenReused {
+			log.Println("Refresh token reuse detected, token family revoked")
+		}
+		if err == models.ErrRefreshTokenInvalid || err == models.ErrRefreshTokenReused {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid or expired refresh token",
+			})
+		}
+		log.Println("Error rotating refresh token:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to refresh token",
+		})
+	}
+
+	// Load the user to put the current username and role in the new access token
+	user, err := models.GetUserByID(db, userID)
+	if err != nil || user == nil {
+		log.Println("Error fetching user for refresh:", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired refresh token",
+		})
+	}
+	if user.Suspended() {
+		return accountSuspended(c)
+	}
+
+	accessToken, err := utils.GenerateJWT(user.Username, user.Role)
+	if err != nil {
+		log.Println("Error generating JWT:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to generate token",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":        "success",
+		"message":       "Token refreshed successfully",
+		"token":         accessToken,
+		"refresh_token": newRefreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
+	})
+}
@@ -1,10 +1,11 @@
 package controllers
 
 import (
-	"GO-X/models"  // Import the models package where we define and interact with the database models
-	"GO-X/utils"   // Import the utils package for utility functions like generating JWT tokens
-	"database/sql" // Import the sql package to interact with the SQL database
-	"log"          // Import the log package to print error messages
+	"GO-X/entities" // Import the entities package to check that usernames can be mentioned
+	"GO-X/models"   // Import the models package where we define and interact with the database models
+	"GO-X/utils"    // Import the utils package for utility functions like generating JWT tokens
+	"database/sql"  // Import the sql package to interact with the SQL database
+	"log"           // Import the log package to print error messages
 
 	"github.com/go-playground/validator/v10" // Import Go validator package for input validation
 	"github.com/gofiber/fiber/v2"            // Import the Fiber web framework to handle HTTP requests
@@ -64,6 +65,14 @@ func RegisterUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Usernames are limited to what a mention can match, so every user can be mentioned
+	if !entities.IsValidUsername(registerRequest.Username) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Usernames may only contain letters, digits and underscores",
+		})
+	}
+
 	// Sanitize the inputs to prevent XSS or other malicious attacks
 	// This ensures that the input doesn't contain harmful characters or scripts
 	registerRequest.Username = sanitizeInput(registerRequest.Username)
@@ -117,9 +126,16 @@ func RegisterUser(c *fiber.Ctx) error {
 		})
 	}
 
-	// Generate a JWT token after successful registration
-	// This token will be used to authenticate the user in future requests
-	token, err := utils.GenerateJWT(registerRequest.Username)
+	// Email a link proving the address belongs to the user; until it is opened, routes that require
+	// a verified email (such as posting tweets) are refused. A failed email doesn't fail the registration,
+	// the user can ask for a new link with POST /auth/resend-verification.
+	if err := sendEmailVerification(&user); err != nil {
+		log.Println("Error sending verification email:", err)
+	}
+
+	// Generate a JWT token and a refresh token after successful registration
+	// The JWT authenticates the user in future requests, the refresh token renews it when it expires
+	tokens, err := issueTokens(&user)
 	if err != nil {
 		log.Println("Error generating JWT:", err)
 		// If there’s an error generating the token, return a 500 Internal Server Error
@@ -132,9 +148,12 @@ func RegisterUser(c *fiber.Ctx) error {
 	// Return the JWT token along with a success message
 	// This response lets the user know they’ve successfully registered and now have a token
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"status":  "success",
-		"message": "User registered successfully", // Success message for registration
-		"token":   token,                          // Send the generated JWT token to the client
+		"status":         "success",
+		"message":        "User registered successfully",      // Success message for registration
+		"token":          tokens.AccessToken,                  // Send the generated JWT token to the client
+		"refresh_token":  tokens.RefreshToken,                 // Send the refresh token used to get new JWTs
+		"expires_in":     int(utils.AccessTokenTTL.Seconds()), // Number of seconds before the JWT expires
+		"email_verified": false,                               // The verification link was just emailed
 	})
 }
 
@@ -1,96 +1,187 @@
-package controller
+package controllers
 
 import (
-	"GO-X/models"
-	"GO-X/utils"
-	"database/sql"
-	"log"
+	"GO-X/models" // Import the models package to check the password and delete the user
+	"log"         // Import the log package to print error messages
+	"time"        // To work with the deletion grace period
 
-	"github.com/go-playground/validator/v10"
-	"github.com/gofiber/fiber/v2"
+	"github.com/go-playground/validator/v10" // Import Go validator package for input validation
+	"github.com/gofiber/fiber/v2"            // Import the Fiber web framework to handle HTTP requests
 )
 
-var db *sgl.dB
+// accountDeletionGracePeriod is how long a deleted account can still be restored
+// When it is zero (the default) accounts are removed immediately
+var accountDeletionGracePeriod time.Duration
 
-func SetDB(database *sql.DB) {
-	db = Database
+// SetAccountDeletionGracePeriod sets how long deleted accounts are kept before they are purged
+func SetAccountDeletionGracePeriod(period time.Duration) {
+	accountDeletionGracePeriod = period
 }
 
-func RemoveUse(c *fiber.Ctx) error {
+// RemoveRequest struct defines the expected data to delete an account
+// The password is asked again, so a stolen token alone can't delete the account
+type RemoveRequest struct {
+	Password string `json:"password" validate:"required"`
+}
 
-	type RemoveRequest struct {
-		Username string `json:"username" validate:"required,min=3, max=50"`
-		Email    string `json:"email" validate:"required,email"`
-		Password string `json:"password" validate:"required,min=6, max=50"`
+// RestoreRequest struct defines the expected data to restore a deleted account
+type RestoreRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
 
-		Jwt string `json:"jwt"`
+// RemoveUser handles DELETE /users/me
+// It checks the password of the authenticated user, revokes all their tokens and deletes the account.
+// Tweets, likes, retweets and followers are removed by the ON DELETE CASCADE foreign keys.
+// With a grace period configured, the account is only soft-deleted and can be restored until it is purged.
+func RemoveUser(c *fiber.Ctx) error {
+	// Check if the request body is empty
+	if c.Body() == nil || len(c.Body()) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Request body cannot be empty",
+		})
 	}
 
-	if c.body() == nill || lin(c.Body()) == 0 {
+	// Parse the incoming request body into the RemoveRequest struct
+	var removeRequest RemoveRequest
+	if err := c.BodyParser(&removeRequest); err != nil {
 		log.Println("BodyParser error:", err)
-
 		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-			"status":  "Error",
-			"Massage": "Problem with Input",
-			"error":   err.Error(),
+			"status":  "error",
+			"message": "Review your input",
+			"errors":  err.Error(),
 		})
 	}
 
+	// Validate the parsed input using the Go validator package
 	validate := validator.New()
-	if err := validate.Struct(registerRequest); err != nil {
-		// If validation fails, return a 400 Bad Request with the validation error message
+	if err := validate.Struct(removeRequest); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
-			"message": "Invalid request body", // Generic error message for validation failure
-			"errors":  err.Error(),            // Return the detailed error message
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+		})
+	}
+
+	// Re-confirm the password of the user the token belongs to
+	user, err := models.GetUserByUsernameAndPassword(db, getClaimsUsername(c), removeRequest.Password)
+	if err != nil && err != models.ErrPasswordMismatch {
+		log.Println("Error fetching user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid credentials",
 		})
 	}
 
-	RemoveRequest.Username = sanitizeInput(RemoveRequest.Username)
-	RemoveRequest.Email = sanitizeInput(RemoveRequest.Email)
-	RemoveRequest.Password = sanitizeInput(RemoveRequest.Password)
-	RemoveRequest.JWT = sanitizeInput(RemoveRequest.JWT)
+	// Revoke every token first, so the account can't be used while it is being deleted
+	if err := revokeAllSessions(user); err != nil {
+		log.Println("Error revoking sessions:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to delete account",
+		})
+	}
 
-	existingUser, err := models.GetUserByUsernameAndPassword(db, RemoveRequest.username, RemoveRequest.Password)
+	// With a grace period, only mark the account as deleted
+	if accountDeletionGracePeriod > 0 {
+		if err := models.SoftDeleteUser(db, user.ID); err != nil {
+			log.Println("Error soft-deleting user:", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to delete account",
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":         "success",
+			"message":        "Account scheduled for deletion",
+			"restore_before": time.Now().Add(accountDeletionGracePeriod),
+		})
+	}
 
-	if err != nil {
-		log.Println("Error checking for existing user:", err)
-		// If there’s an error checking the database, return a 500 Internal Server Error
+	if err := models.DeleteUser(db, user.ID); err != nil {
+		log.Println("Error deleting user:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"status":  "error",
-			"message": "Internal server error", // General error message for server issues
-			"Error":   err.Error(),
+			"message": "Failed to delete account",
 		})
 	}
-	if existingUser != nil {
-		// If the username is already taken, return a 400 Bad Request with an error message
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Account deleted successfully",
+	})
+}
+
+// RestoreUser handles POST /auth/restore-account
+// It brings back a soft-deleted account during the grace period and logs the user in again
+func RestoreUser(c *fiber.Ctx) error {
+	// Check if the request body is empty
+	if c.Body() == nil || len(c.Body()) == 0 {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"status":  "error",
-			"message": "Username already taken", // Message indicating the username is already in use
-			"Error":   err.Error(),
+			"message": "Request body cannot be empty",
 		})
 	}
 
-	ValidJWT, err := utils.ValidateJWT(RemoveRequest.JWT)
+	// Parse the incoming request body into the RestoreRequest struct
+	var restoreRequest RestoreRequest
+	if err := c.BodyParser(&restoreRequest); err != nil {
+		log.Println("BodyParser error:", err)
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Review your input",
+			"errors":  err.Error(),
+		})
+	}
 
-	log.Println("Error Problem with the JWT:", err)
+	// Validate the parsed input using the Go validator package
+	validate := validator.New()
+	if err := validate.Struct(restoreRequest); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+		})
+	}
+
+	// Restoring an account checks the password too, so it shares the lockout of the login
+	if ok, err := reserveLoginAttempt(c, restoreRequest.Username); !ok {
+		return err
+	}
 
-	if err != nill {
+	user, deletedAt, err := models.GetDeletedUserByUsernameAndPassword(db, restoreRequest.Username, restoreRequest.Password)
+	if err != nil && err != models.ErrPasswordMismatch {
+		log.Println("Error fetching deleted user:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"Status":  "error",
-			"message": "JWT Invalid",
-			"Error":   err.Error(),
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user != nil {
+		releaseLoginAttempt(c, restoreRequest.Username) // The password was right
+	}
+	if user == nil || time.Since(deletedAt) > accountDeletionGracePeriod {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid credentials or account can no longer be restored",
 		})
 	}
 
-	if ok != nill {
+	if err := models.RestoreUser(db, user.ID); err != nil {
+		log.Println("Error restoring user:", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"Status":  "error",
-			"message": "JWT Invalid",
-			"Error":   err.Error(),
+			"status":  "error",
+			"message": "Failed to restore account",
 		})
 	}
 
-	return nill, error.New(" impossible to delete the user data")
-
+	// The old tokens were revoked on deletion, so start a new session (asking for the 2FA code if enabled)
+	return completeLogin(c, user, "Account restored successfully")
 }
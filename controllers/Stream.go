@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"GO-X/realtime" // Import the realtime package to subscribe to the user's events
+	"bufio"         // The response body is written through a buffered writer
+	"encoding/json" // To encode the event payloads
+	"fmt"           // To format the event-stream fields
+	"log"           // Import the log package to print error messages
+	"strconv"       // To parse the Last-Event-ID header
+	"time"          // To send heartbeats and close the stream when the token expires
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+	"github.com/valyala/fasthttp" // Fiber's HTTP engine, which streams the response body
+)
+
+// sseHeartbeatInterval is how often a comment line is sent on idle streams, so proxies don't close them
+const sseHeartbeatInterval = 15 * time.Second
+
+// sseRetry is the reconnection delay suggested to clients, in milliseconds
+const sseRetry = 3000
+
+// StreamEvents handles GET /stream/events
+// It sends the same events as /ws, as Server-Sent Events, for clients behind proxies that break WebSockets.
+// A client that reconnects with the Last-Event-ID header (or ?last_event_id=) first receives the events
+// it missed, as long as they are still in the hub's replay buffer.
+func StreamEvents(c *fiber.Ctx) error {
+	if hub == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Real-time events are disabled",
+		})
+	}
+
+	user, err := getCurrentUser(c)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+	}
+
+	// Browsers send Last-Event-ID on their own when they reconnect; other clients may use the query string
+	lastEventID := c.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var since uint64
+	resume := lastEventID != ""
+	if resume {
+		since, err = strconv.ParseUint(lastEventID, 10, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Invalid Last-Event-ID",
+			})
+		}
+	}
+
+	// Subscribe before the response starts, so nothing published from now on is missed
+	sub, missed := hub.SubscribeSince(user.ID, since, resume)
+	expiresAt := tokenExpiry(getClaims(c))
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no") // Stop nginx from buffering the stream
+
+	c.Context().SetBodyStreamWriter(fasthttp.StreamWriter(func(w *bufio.Writer) {
+		defer sub.Close()
+
+		fmt.Fprintf(w, "retry: %d\n\n", sseRetry)
+		for _, event := range missed {
+			if writeServerSentEvent(w, event) != nil {
+				return
+			}
+		}
+		if w.Flush() != nil {
+			return
+		}
+
+		// The stream must not outlive the token it was opened with
+		expiry := time.NewTimer(time.Until(expiresAt))
+		defer expiry.Stop()
+		heartbeat := time.NewTicker(sseHeartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case event := <-sub.Events():
+				if writeServerSentEvent(w, event) != nil {
+					return
+				}
+			case <-heartbeat.C:
+				// Writing is also how we notice that the client went away
+				if _, err := w.WriteString(": ping\n\n"); err != nil || w.Flush() != nil {
+					return
+				}
+			case <-sub.Done():
+				// The hub gave up on this client because it was too slow; it will reconnect and resume
+				return
+			case <-expiry.C:
+				fmt.Fprint(w, "event: token_expired\ndata: {}\n\n")
+				w.Flush()
+				return
+			}
+		}
+	}))
+	return nil
+}
+
+// writeServerSentEvent writes one event in the text/event-stream format and flushes it
+// The id field lets the client resume from this event after a reconnect
+func writeServerSentEvent(w *bufio.Writer, event realtime.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Println("Error encoding event:", err)
+		return nil // Skip the event rather than closing the stream
+	}
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"GO-X/models" // Import the models package to load conversations
+	"GO-X/utils"  // Import the utils package to encode cursors
+	"log"         // Import the log package to print error messages
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+const (
+	maxThreadAncestors = 50 // Most tweets shown above the requested one
+	defaultThreadDepth = 2  // Levels of replies loaded when ?depth= is missing
+	maxThreadDepth     = 5  // Deepest ?depth= a client may ask for
+	threadBranchSize   = 3  // Replies loaded under each reply below the first level
+)
+
+// GetThread handles GET /tweets/:id/thread
+// It returns the tweet, the chain of tweets it replies to (oldest first), and the tree of replies under it.
+// The first level of replies is paginated with ?cursor= and ?limit=, oldest first; ?depth= (1 to 5) sets
+// how many levels are loaded, with at most 3 replies per tweet below the first level. A reply whose
+// reply_count is higher than the replies shown can be opened with its own /thread.
+func GetThread(c *fiber.Ctx) error {
+	tweet, user, err := loadTweetForUser(c)
+	if tweet == nil {
+		return err
+	}
+
+	var cursor idCursor
+	if !parseCursor(c, &cursor) {
+		return invalidCursor(c)
+	}
+	limit := parseLimit(c)
+	depth := c.QueryInt("depth", defaultThreadDepth)
+	if depth < 1 || depth > maxThreadDepth {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "depth must be between 1 and 5",
+		})
+	}
+
+	ancestors, err := models.GetAncestors(db, tweet, maxThreadAncestors)
+	if err != nil {
+		log.Println("Error fetching ancestors:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	replies, err := loadReplyTree(tweet.ID, cursor.ID, limit, depth)
+	if err != nil {
+		log.Println("Error fetching replies:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	shownTweets := collectThreadTweets(append([]*models.Tweet{tweet}, ancestors...), replies)
+	if shown, err := hideBlockedQuotes(c, user.ID, shownTweets...); !shown {
+		return err
+	}
+
+	// A full page means there may be more: hand out a cursor pointing after the last reply
+	var nextCursor string
+	if len(replies) == limit {
+		nextCursor = utils.EncodeCursor(idCursor{ID: replies[len(replies)-1].Tweet.ID})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"tweet":       tweet,
+		"ancestors":   ancestors,
+		"replies":     replies,
+		"next_cursor": nextCursor,
+	})
+}
+
+// loadReplyTree loads one page of direct replies to a tweet, then the replies under them, level by level
+// Each level below the first is loaded with a single query
+func loadReplyTree(tweetID int, afterID int, limit int, depth int) ([]models.ThreadNode, error) {
+	top, err := models.GetReplies(db, tweetID, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]models.ThreadNode, len(top))
+	level := make([]*models.ThreadNode, len(top))
+	for i, reply := range top {
+		roots[i] = models.ThreadNode{Tweet: reply, Replies: []models.ThreadNode{}}
+		level[i] = &roots[i]
+	}
+
+	for d := 2; d <= depth && len(level) > 0; d++ {
+		// Only ask for the replies of tweets that have some
+		parentIDs := []int{}
+		for _, node := range level {
+			if node.Tweet.ReplyCount > 0 {
+				parentIDs = append(parentIDs, node.Tweet.ID)
+			}
+		}
+		children, err := models.GetRepliesOf(db, parentIDs, threadBranchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		next := []*models.ThreadNode{}
+		for _, node := range level {
+			node.Replies = make([]models.ThreadNode, len(children[node.Tweet.ID]))
+			for i, child := range children[node.Tweet.ID] {
+				node.Replies[i] = models.ThreadNode{Tweet: child, Replies: []models.ThreadNode{}}
+				next = append(next, &node.Replies[i])
+			}
+		}
+		level = next
+	}
+	return roots, nil
+}
+
+// collectThreadTweets appends the tweets of a reply tree, at every level, to tweets
+func collectThreadTweets(tweets []*models.Tweet, nodes []models.ThreadNode) []*models.Tweet {
+	for _, node := range nodes {
+		tweets = collectThreadTweets(append(tweets, node.Tweet), node.Replies)
+	}
+	return tweets
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"GO-X/models"   // Import the models package to read the timeline
+	"GO-X/timeline" // Import the timeline package for materialized timelines
+	"GO-X/utils"    // Import the utils package to build pagination cursors
+	"log"           // Import the log package to print error messages
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+var timelines *timeline.Service // Declare a variable to store the timeline service
+
+// SetTimeline sets the service used to materialize home timelines
+// When it isn't set, home timelines are computed with a JOIN on every request
+func SetTimeline(service *timeline.Service) {
+	timelines = service
+}
+
+// updateTimelines runs a timeline update in the background, so fan-out doesn't slow the request down
+// Errors are only logged: a timeline that missed an update can be repaired with a rebuild
+func updateTimelines(description string, update func(service *timeline.Service) error) {
+	if timelines == nil {
+		return
+	}
+	go func() {
+		if err := update(timelines); err != nil {
+			log.Println("Error updating timelines ("+description+"):", err)
+		}
+	}()
+}
+
+// GetHomeTimeline handles GET /timeline/home
+// It returns the caller's own tweets merged with the tweets and retweets of the accounts they follow,
+// newest first. ?cursor= is the opaque next_cursor of the previous page.
+func GetHomeTimeline(c *fiber.Ctx) error {
+	user, err := getCurrentUser(c)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+	}
+
+	// Read where the previous page stopped, if any
+	var after *models.TimelinePosition
+	if c.Query("cursor") != "" {
+		after = &models.TimelinePosition{}
+		if !parseCursor(c, after) {
+			return invalidCursor(c)
+		}
+	}
+	limit := parseLimit(c)
+
+	// Read the materialized timeline when fan-out is enabled, or compute it from the tables otherwise
+	var entries []models.TimelineEntry
+	if timelines != nil {
+		entries, err = timelines.Home(user.ID, after, limit)
+	} else {
+		entries, err = models.GetHomeTimelineEntries(db, user.ID, after, limit)
+	}
+	if err != nil {
+		log.Println("Error reading home timeline:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	items, err := models.HydrateTimeline(db, entries)
+	if err != nil {
+		log.Println("Error loading timeline tweets:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+
+	shownTweets := make([]*models.Tweet, len(items))
+	for i, item := range items {
+		shownTweets[i] = item.Tweet
+	}
+	if shown, err := hideBlockedQuotes(c, user.ID, shownTweets...); !shown {
+		return err
+	}
+
+	// The cursor points at the last entry read, even if its tweet was skipped during hydration
+	var nextCursor string
+	if len(entries) == limit {
+		nextCursor = utils.EncodeCursor(entries[len(entries)-1].TimelinePosition)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":      "success",
+		"timeline":    items,
+		"next_cursor": nextCursor,
+	})
+}
+
+// RebuildHomeTimeline handles POST /timeline/home/rebuild
+// It recomputes the caller's materialized timeline from the tweets, retweets and followers tables
+func RebuildHomeTimeline(c *fiber.Ctx) error {
+	if timelines == nil {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status":  "success",
+			"message": "Timelines are not materialized, nothing to rebuild",
+		})
+	}
+
+	user, err := getCurrentUser(c)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+	}
+
+	count, err := timelines.Rebuild(user.ID)
+	if err != nil {
+		log.Println("Error rebuilding timeline:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to rebuild timeline",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Timeline rebuilt",
+		"entries": count,
+	})
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"GO-X/trends" // Import the trends package to read the cached rankings
+
+	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+)
+
+var trendTracker *trends.Tracker // Declare a variable to store the trends tracker, nil when trends are disabled
+
+// SetTrends sets the tracker whose rankings GET /trends serves
+// This function is called from the main app, like SetDB; the app also refreshes the tracker in the background
+func SetTrends(tracker *trends.Tracker) {
+	trendTracker = tracker
+}
+
+// GetTrends handles GET /trends
+// It returns the trending hashtags and terms computed by the last background refresh; nothing is computed per request
+func GetTrends(c *fiber.Ctx) error {
+	if trendTracker == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Trends are not available",
+		})
+	}
+
+	rankings := trendTracker.Rankings()
+	response := fiber.Map{
+		"status":      "success",
+		"hashtags":    rankings.Hashtags,
+		"terms":       rankings.Terms,
+		"computed_at": nil, // Until the first refresh is done
+	}
+	if !rankings.ComputedAt.IsZero() {
+		response["computed_at"] = rankings.ComputedAt
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
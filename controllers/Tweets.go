@@ -0,0 +1,408 @@
+package controllers
+
+import (
+	"GO-X/models"   // Import the models package to read and write tweets
+	"GO-X/realtime" // Import the realtime package to push new tweets to followers
+	"GO-X/timeline" // Import the timeline package to fan new tweets out
+	"log"           // Import the log package to print error messages
+	"strings"       // To trim whitespace around the content
+	"time"          // Media older than the orphan TTL are refused
+
+	"github.com/go-playground/validator/v10" // Import Go validator package for input validation
+	"github.com/gofiber/fiber/v2"            // Import the Fiber web framework to handle HTTP requests
+)
+
+// TweetRequest struct defines the expected data to post or edit a tweet
+// A new tweet may have no text when it carries media. Media, the reply target and the quoted tweet can't be
+// changed by an edit, so MediaIDs, InReplyToTweetID and QuotedTweetID are ignored there.
+type TweetRequest struct {
+	Content          string `json:"content" validate:"required_without=MediaIDs,max=280"`  // The text of the tweet, at most 280 characters
+	MediaIDs         []int  `json:"media_ids" validate:"omitempty,max=4,unique,dive,gt=0"` // IDs returned by POST /media, at most 4
+	InReplyToTweetID *int   `json:"in_reply_to_tweet_id" validate:"omitempty,gt=0"`        // The tweet this one replies to, if any
+	QuotedTweetID    *int   `json:"quoted_tweet_id" validate:"omitempty,gt=0"`             // The tweet this one quotes, if any
+}
+
+// parseTweetRequest parses and validates the body of a create or edit request
+// When something is wrong it writes the error response and returns nil
+func parseTweetRequest(c *fiber.Ctx) (*TweetRequest, error) {
+	// Check if the request body is empty
+	if c.Body() == nil || len(c.Body()) == 0 {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Request body cannot be empty",
+		})
+	}
+
+	// Parse the incoming request body into the TweetRequest struct
+	var tweetRequest TweetRequest
+	if err := c.BodyParser(&tweetRequest); err != nil {
+		log.Println("BodyParser error:", err)
+		return nil, c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Review your input",
+			"errors":  err.Error(),
+		})
+	}
+
+	// Validate the content using the Go validator package (after trimming, so blank tweets are rejected)
+	tweetRequest.Content = sanitizeInput(strings.TrimSpace(tweetRequest.Content))
+	validate := validator.New()
+	if err := validate.Struct(tweetRequest); err != nil {
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid request body",
+			"errors":  err.Error(),
+		})
+	}
+	return &tweetRequest, nil
+}
+
+// CreateTweet handles POST /tweets
+// It posts a new tweet as the authenticated user
+func CreateTweet(c *fiber.Ctx) error {
+	tweetRequest, err := parseTweetRequest(c)
+	if tweetRequest == nil {
+		return err
+	}
+
+	user, err := getCurrentUser(c)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+	}
+
+	if tweetRequest.Content == "" && len(tweetRequest.MediaIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "A tweet needs some text or media",
+		})
+	}
+
+	tweet := models.Tweet{
+		UserID:  user.ID,
+		Content: tweetRequest.Content,
+	}
+
+	// A reply joins the conversation of the tweet it answers
+	var parent *models.Tweet
+	if tweetRequest.InReplyToTweetID != nil {
+		parent, err = models.GetTweetByID(db, *tweetRequest.InReplyToTweetID)
+		if err != nil {
+			log.Println("Error fetching parent tweet:", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Internal server error",
+			})
+		}
+		if parent == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "The tweet you are replying to doesn't exist",
+			})
+		}
+		tweet.InReplyToTweetID = &parent.ID
+		tweet.ConversationID = parent.ConversationID
+	}
+
+	// A quote embeds another tweet, which its author may forbid by blocking the user
+	var quoted *models.Tweet
+	if tweetRequest.QuotedTweetID != nil {
+		quoted, err = models.GetTweetByID(db, *tweetRequest.QuotedTweetID)
+		if err != nil {
+			log.Println("Error fetching quoted tweet:", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Internal server error",
+			})
+		}
+		if quoted == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"status":  "error",
+				"message": "The tweet you are quoting doesn't exist",
+			})
+		}
+		blocked, err := models.IsBlocking(db, quoted.UserID, user.ID)
+		if err != nil {
+			log.Println("Error checking block:", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Internal server error",
+			})
+		}
+		if blocked {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": "You can't quote this tweet",
+			})
+		}
+		tweet.QuotedTweetID = &quoted.ID
+	}
+
+	if err := tweet.Create(db); err != nil {
+		log.Println("Error creating tweet:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to post tweet",
+		})
+	}
+
+	// Attach the uploaded media; if one of them can't be used, the tweet is taken back
+	if len(tweetRequest.MediaIDs) > 0 {
+		attached, err := attachMedia(c, user, &tweet, tweetRequest.MediaIDs)
+		if attached == nil {
+			return err
+		}
+	}
+
+	// Push the tweet into the timelines of the author's followers
+	updateTimelines("new tweet", func(service *timeline.Service) error {
+		return service.OnTweet(&tweet)
+	})
+
+	// Let the followers who are online know right away
+	publishTweetToFollowers(realtime.EventTweet, &tweet)
+
+	// Tell the author of the tweet being answered (but not users replying to themselves)
+	if parent != nil && parent.UserID != user.ID {
+		recordNotification(parent.UserID, user.ID, models.NotificationReply, tweet.ID)
+		publishTweetEvent(parent.UserID, realtime.EventReply, fiber.Map{
+			"user":        models.UserRef{ID: user.ID, Username: user.Username},
+			"tweet":       tweet,
+			"in_reply_to": parent,
+		})
+	}
+
+	// Tell the author of the quoted tweet (but not users quoting themselves)
+	if quoted != nil && quoted.UserID != user.ID {
+		recordNotification(quoted.UserID, user.ID, models.NotificationQuote, tweet.ID)
+		publishTweetEvent(quoted.UserID, realtime.EventQuote, fiber.Map{
+			"user":  models.UserRef{ID: user.ID, Username: user.Username},
+			"tweet": tweet,
+		})
+	}
+
+	// Tell the mentioned users, except those who were just told about the tweet as its parent's or quoted tweet's author
+	notified := []int{}
+	if parent != nil {
+		notified = append(notified, parent.UserID)
+	}
+	if quoted != nil {
+		notified = append(notified, quoted.UserID)
+	}
+	notifyMentions(&tweet, notified...)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Tweet posted successfully",
+		"tweet":   tweet,
+	})
+}
+
+// GetTweet handles GET /tweets/:id
+// It returns a single tweet
+func GetTweet(c *fiber.Ctx) error {
+	tweetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid tweet ID",
+		})
+	}
+
+	tweet, err := models.GetTweetByID(db, tweetID)
+	if err != nil {
+		log.Println("Error fetching tweet:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if tweet == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Tweet not found",
+		})
+	}
+
+	viewer, err := loadCurrentUser(c)
+	if viewer == nil {
+		return err
+	}
+	if shown, err := hideBlockedQuotes(c, viewer.ID, tweet); !shown {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "success",
+		"tweet":  tweet,
+	})
+}
+
+// UpdateTweet handles PATCH /tweets/:id
+// Only the author of a tweet may edit it
+func UpdateTweet(c *fiber.Ctx) error {
+	tweet, err := loadOwnTweet(c)
+	if tweet == nil {
+		return err
+	}
+
+	tweetRequest, err := parseTweetRequest(c)
+	if tweetRequest == nil {
+		return err
+	}
+
+	mentionedBefore := tweet.MentionedUserIDs()
+	if err := tweet.UpdateContent(db, tweetRequest.Content); err != nil {
+		log.Println("Error updating tweet:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to update tweet",
+		})
+	}
+	// Only the users the edit adds are notified
+	notifyMentions(tweet, mentionedBefore...)
+	if shown, err := hideBlockedQuotes(c, tweet.UserID, tweet); !shown {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Tweet updated successfully",
+		"tweet":   tweet,
+	})
+}
+
+// DeleteTweet handles DELETE /tweets/:id
+// Only the author of a tweet may delete it
+func DeleteTweet(c *fiber.Ctx) error {
+	tweet, err := loadOwnTweet(c)
+	if tweet == nil {
+		return err
+	}
+
+	if err := removeTweet(tweet); err != nil {
+		log.Println("Error deleting tweet:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Failed to delete tweet",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
+		"message": "Tweet deleted successfully",
+	})
+}
+
+// removeTweet deletes a tweet and takes it out of the home timelines it was fanned out to
+// It is shared by authors deleting their tweets and moderators removing someone else's
+func removeTweet(tweet *models.Tweet) error {
+	if err := models.DeleteTweet(db, tweet.ID); err != nil {
+		return err
+	}
+	updateTimelines("deleted tweet", func(service *timeline.Service) error {
+		return service.OnTweetDeleted(tweet.ID)
+	})
+	return nil
+}
+
+// loadOwnTweet loads the tweet named by the ":id" parameter and checks that the authenticated user wrote it
+// When the tweet can't be used it writes the error response and returns a nil tweet
+func loadOwnTweet(c *fiber.Ctx) (*models.Tweet, error) {
+	tweet, user, err := loadTweetForUser(c)
+	if tweet == nil {
+		return nil, err
+	}
+
+	// Only the author may change or delete the tweet
+	if tweet.UserID != user.ID {
+		return nil, c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "You can only modify your own tweets",
+		})
+	}
+	return tweet, nil
+}
+
+// loadTweetForUser loads the authenticated user and the tweet named by the ":id" parameter
+// When either can't be loaded it writes the error response and returns a nil tweet
+func loadTweetForUser(c *fiber.Ctx) (*models.Tweet, *models.User, error) {
+	tweetID, ok := parseIDParam(c, "id")
+	if !ok {
+		return nil, nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid tweet ID",
+		})
+	}
+
+	user, err := getCurrentUser(c)
+	if err != nil {
+		log.Println("Error fetching user:", err)
+		return nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if user == nil {
+		return nil, nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+	}
+
+	tweet, err := models.GetTweetByID(db, tweetID)
+	if err != nil {
+		log.Println("Error fetching tweet:", err)
+		return nil, nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if tweet == nil {
+		return nil, nil, c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Tweet not found",
+		})
+	}
+	return tweet, user, nil
+}
+
+// attachMedia attaches uploaded media to a tweet that was just created and reloads it
+// When the media can't be attached it deletes the tweet, writes the error response and returns nil
+func attachMedia(c *fiber.Ctx, user *models.User, tweet *models.Tweet, mediaIDs []int) (*models.Tweet, error) {
+	attached, err := models.AttachMedia(db, user.ID, tweet.ID, mediaIDs, time.Now().Add(-mediaOrphanTTL))
+	if err != nil || !attached {
+		if err := models.DeleteTweet(db, tweet.ID); err != nil {
+			log.Println("Error deleting tweet after failed media attachment:", err)
+		}
+		if err != nil {
+			log.Println("Error attaching media:", err)
+			return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Failed to post tweet",
+			})
+		}
+		return nil, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Unknown or expired media, or media already attached to a tweet",
+		})
+	}
+
+	// Read the tweet back with its media
+	updated, err := models.GetTweetByID(db, tweet.ID)
+	if err != nil || updated == nil {
+		log.Println("Error reloading tweet:", err)
+		return tweet, nil
+	}
+	*tweet = *updated
+	return tweet, nil
+}
@@ -11,7 +11,18 @@ CREATE TABLE IF NOT EXISTS users (
     email VARCHAR(100) NOT NULL UNIQUE,
     password VARCHAR(255) NOT NULL,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL DEFAULT NULL, -- Set when the account is soft-deleted (see database/migrations)
+    email_verified BOOLEAN NOT NULL DEFAULT FALSE, -- Set once the user clicked the link sent to their email address
+    role VARCHAR(20) NOT NULL DEFAULT 'user', -- 'user', 'moderator' or 'admin'
+    suspended_at TIMESTAMP NULL DEFAULT NULL, -- Set while the account is suspended by a moderator
+    display_name VARCHAR(50) NOT NULL DEFAULT '', -- Profile fields, all optional (see database/migrations)
+    bio VARCHAR(160) NOT NULL DEFAULT '',
+    location VARCHAR(30) NOT NULL DEFAULT '',
+    website VARCHAR(100) NOT NULL DEFAULT '',
+    avatar_url VARCHAR(255) NOT NULL DEFAULT '',
+    banner_url VARCHAR(255) NOT NULL DEFAULT '',
+    hide_profile_details BOOLEAN NOT NULL DEFAULT FALSE -- Only show the profile details and follow lists to followers; tweets stay public
 );
 
 -- Tweets Table: Stores tweets
@@ -19,9 +30,13 @@ CREATE TABLE IF NOT EXISTS tweets (
     id INT AUTO_INCREMENT PRIMARY KEY,
     user_id INT NOT NULL,
     content TEXT NOT NULL,
+    in_reply_to_tweet_id INT NULL DEFAULT NULL, -- The tweet this one replies to
+    conversation_id INT NULL DEFAULT NULL, -- The first tweet of the conversation; NULL for that first tweet itself
+    quoted_tweet_id INT NULL DEFAULT NULL, -- The tweet this one quotes; no foreign key, so a deleted quoted tweet shows as a tombstone
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
-    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (in_reply_to_tweet_id) REFERENCES tweets(id) ON DELETE SET NULL -- Replies survive the deletion of their parent
 );
 
 -- Followers Table: Stores user-following relationships
@@ -67,6 +82,179 @@ CREATE TABLE IF NOT EXISTS password_resets (
     FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
 );
 
+-- Refresh Tokens Table: Stores hashed refresh tokens, grouped in families for rotation
+-- Every refresh creates a new token in the same family and marks the old one as used.
+-- Presenting a used token again means it was stolen, so the whole family gets revoked.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    family_id VARCHAR(64) NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    revoked_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Revoked Tokens Table: Stores the jti of access tokens revoked before they expired (logout)
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti VARCHAR(64) PRIMARY KEY,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- User Token Revocations Table: Every token of a user issued at or before revoked_before is rejected ("log out everywhere")
+CREATE TABLE IF NOT EXISTS user_token_revocations (
+    username VARCHAR(50) PRIMARY KEY,
+    revoked_before TIMESTAMP(3) NOT NULL -- Milliseconds, like the "iat" claim of the tokens
+);
+
+-- Timeline Entries Table: Materialized home timelines, filled by fan-out-on-write
+-- kind is 0 for tweets (item_id = tweet id) and 1 for retweets (item_id = retweets.id);
+-- source_id is the followed account the entry comes from (the author, or the retweeter)
+CREATE TABLE IF NOT EXISTS timeline_entries (
+    user_id INT NOT NULL,
+    kind TINYINT NOT NULL,
+    item_id INT NOT NULL,
+    tweet_id INT NOT NULL,
+    source_id INT NOT NULL,
+    activity_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, kind, item_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Notifications Table: The inbox of each user (likes, retweets, follows, replies and mentions)
+-- Repeated events are grouped while unread: every notification with the same group_key (e.g. "like:42")
+-- gains one more actor instead of creating a new row. The actors are listed in notification_actors.
+-- open_group_key is the group key until the notification is read, so each user has one open group per key.
+CREATE TABLE IF NOT EXISTS notifications (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    tweet_id INT NULL,
+    group_key VARCHAR(64) NOT NULL,
+    open_group_key VARCHAR(64) NULL DEFAULT NULL,
+    actor_count INT NOT NULL DEFAULT 1,
+    read_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Bumped when an actor joins the group (not when it is read)
+    UNIQUE KEY uq_notifications_open_group (user_id, open_group_key),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE CASCADE
+);
+
+-- Notification Actors Table: Everyone who took part in a grouped notification
+CREATE TABLE IF NOT EXISTS notification_actors (
+    notification_id INT NOT NULL,
+    actor_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (notification_id, actor_id),
+    FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Login Throttles Table: Failed login attempts per account (scope "user") and per client IP (scope "ip")
+-- locked_until delays the next attempt, growing exponentially with the failures, up to a temporary lockout
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope VARCHAR(10) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NULL DEFAULT NULL,
+    locked_until TIMESTAMP NULL DEFAULT NULL,
+    PRIMARY KEY (scope, subject)
+);
+
+-- User MFA Table: TOTP two-factor authentication of a user
+-- The secret is pending until confirmed_at is set by a first valid code; last_used_step stops a code from being replayed
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_id INT PRIMARY KEY,
+    secret VARCHAR(64) NOT NULL,
+    confirmed_at TIMESTAMP NULL DEFAULT NULL,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- MFA Recovery Codes Table: One-time codes replacing a TOTP code, stored as SHA-256 hashes
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, code_hash),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Email Verifications Table: Single-use tokens emailed to prove the user owns their email address
+-- Like password resets, only the SHA-256 hash of the token is stored
+CREATE TABLE IF NOT EXISTS email_verifications (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    token VARCHAR(255) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expired_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+-- Media Table: Uploaded images, stored outside the database (see the storage package)
+-- tweet_id is NULL until the media is attached to a tweet; media left unattached are garbage-collected
+CREATE TABLE IF NOT EXISTS media (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    tweet_id INT NULL DEFAULT NULL,
+    position TINYINT NOT NULL DEFAULT 0, -- Order of the media in the tweet
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes INT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE SET NULL -- Deleting a tweet orphans its media
+);
+
+-- Blocks Table: A blocked user can't follow the blocker, and the blocker's tweets show as tombstones in their quotes
+CREATE TABLE IF NOT EXISTS blocks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    blocker_id INT NOT NULL,
+    blocked_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT UNIQUE(blocker_id, blocked_id) -- A user can block another user only once
+);
+
+-- Hashtags Table: Every hashtag used in a tweet, normalized (composed and lower-cased)
+-- The binary collation keeps distinct tags apart, e.g. #café and #cafe
+CREATE TABLE IF NOT EXISTS hashtags (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    tag VARCHAR(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Tweet Hashtags Table: Which tweets carry which hashtags, kept in sync with the content of the tweets
+CREATE TABLE IF NOT EXISTS tweet_hashtags (
+    tweet_id INT NOT NULL,
+    hashtag_id INT NOT NULL,
+    PRIMARY KEY (tweet_id, hashtag_id),
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE CASCADE,
+    FOREIGN KEY (hashtag_id) REFERENCES hashtags(id) ON DELETE CASCADE
+);
+
+-- Tweet Mentions Table: Users mentioned in tweets, one row per "@username" naming an existing user
+-- Offsets are in characters (Unicode code points) of the content, kept in sync with it
+CREATE TABLE IF NOT EXISTS tweet_mentions (
+    tweet_id INT NOT NULL,
+    user_id INT NOT NULL,
+    start_offset SMALLINT NOT NULL,
+    end_offset SMALLINT NOT NULL,
+    PRIMARY KEY (tweet_id, start_offset),
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
 -- Indexes for performance (optional but recommended)
 CREATE INDEX idx_user_email ON users (email);
 CREATE INDEX idx_user_username ON users (username);
@@ -74,4 +262,24 @@ CREATE INDEX idx_tweet_user_id ON tweets (user_id);
 CREATE INDEX idx_followers_user_id ON followers (follower_id);
 CREATE INDEX idx_likes_user_id ON likes (user_id);
 CREATE INDEX idx_retweets_user_id ON retweets (user_id);
-
+CREATE INDEX idx_password_resets_token ON password_resets (token);
+CREATE INDEX idx_refresh_tokens_family ON refresh_tokens (family_id);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens (expires_at);
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
+CREATE INDEX idx_tweets_user_created ON tweets (user_id, created_at);
+CREATE INDEX idx_retweets_user_created ON retweets (user_id, created_at);
+CREATE INDEX idx_timeline_entries_order ON timeline_entries (user_id, activity_at, kind, item_id);
+CREATE INDEX idx_timeline_entries_tweet ON timeline_entries (tweet_id);
+CREATE INDEX idx_notifications_order ON notifications (user_id, updated_at, id);
+CREATE INDEX idx_login_throttles_last_failure ON login_throttles (last_failure_at);
+CREATE INDEX idx_users_role ON users (role);
+CREATE INDEX idx_media_tweet ON media (tweet_id, position);
+CREATE INDEX idx_media_orphans ON media (tweet_id, created_at);
+CREATE INDEX idx_tweets_in_reply_to ON tweets (in_reply_to_tweet_id, id);
+CREATE INDEX idx_tweets_conversation ON tweets (conversation_id);
+CREATE INDEX idx_tweets_quoted ON tweets (quoted_tweet_id, id);
+CREATE INDEX idx_blocks_blocked ON blocks (blocked_id, blocker_id);
+CREATE INDEX idx_tweet_hashtags_hashtag ON tweet_hashtags (hashtag_id, tweet_id);
+CREATE INDEX idx_tweet_mentions_user ON tweet_mentions (user_id, tweet_id);
+CREATE INDEX idx_tweets_created ON tweets (created_at, id);
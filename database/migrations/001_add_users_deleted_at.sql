@@ -0,0 +1,4 @@
+-- Adds soft deletion to users
+-- A user with deleted_at set is hidden everywhere and purged once the grace period is over
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP NULL DEFAULT NULL;
+CREATE INDEX idx_users_deleted_at ON users (deleted_at);
@@ -0,0 +1,3 @@
+-- Indexes used by the home timeline, which reads tweets and retweets by author, newest first
+CREATE INDEX idx_tweets_user_created ON tweets (user_id, created_at);
+CREATE INDEX idx_retweets_user_created ON retweets (user_id, created_at);
@@ -0,0 +1,14 @@
+-- Materialized home timelines, filled by fan-out-on-write
+-- Existing timelines can be filled with: go run ./cmd/rebuild-timeline -all
+CREATE TABLE IF NOT EXISTS timeline_entries (
+    user_id INT NOT NULL,
+    kind TINYINT NOT NULL,
+    item_id INT NOT NULL,
+    tweet_id INT NOT NULL,
+    source_id INT NOT NULL,
+    activity_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, kind, item_id),
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_timeline_entries_order ON timeline_entries (user_id, activity_at, kind, item_id);
+CREATE INDEX idx_timeline_entries_tweet ON timeline_entries (tweet_id);
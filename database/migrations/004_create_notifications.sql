@@ -0,0 +1,29 @@
+-- Notifications inbox: likes, retweets, follows, replies and mentions, grouped while unread
+CREATE TABLE IF NOT EXISTS notifications (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    tweet_id INT NULL,
+    group_key VARCHAR(64) NOT NULL,
+    actor_id INT NOT NULL,
+    actor_count INT NOT NULL DEFAULT 1,
+    read_at TIMESTAMP NULL DEFAULT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, -- Bumped when an actor joins the group (not when it is read)
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE CASCADE
+);
+
+-- Notification Actors Table: Everyone who took part in a grouped notification
+CREATE TABLE IF NOT EXISTS notification_actors (
+    notification_id INT NOT NULL,
+    actor_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (notification_id, actor_id),
+    FOREIGN KEY (notification_id) REFERENCES notifications(id) ON DELETE CASCADE,
+    FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_notifications_order ON notifications (user_id, updated_at, id);
+CREATE INDEX idx_notifications_group ON notifications (user_id, group_key, read_at);
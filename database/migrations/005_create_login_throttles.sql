@@ -0,0 +1,11 @@
+-- Failed login tracking, for progressive delays and temporary lockouts
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope VARCHAR(10) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    failures INT NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NULL DEFAULT NULL,
+    locked_until TIMESTAMP NULL DEFAULT NULL,
+    PRIMARY KEY (scope, subject)
+);
+
+CREATE INDEX idx_login_throttles_last_failure ON login_throttles (last_failure_at);
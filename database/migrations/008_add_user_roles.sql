@@ -0,0 +1,5 @@
+-- Adds roles (user, moderator, admin) and account suspension
+-- Every existing account becomes a regular user; promote the first admin with: go run ./cmd/set-role -user <username> -role admin
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user';
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMP NULL DEFAULT NULL;
+CREATE INDEX idx_users_role ON users (role);
@@ -0,0 +1,9 @@
+-- Adds profile fields and the private profile setting
+-- Every field defaults to empty, so existing accounts keep an empty, public profile
+ALTER TABLE users ADD COLUMN display_name VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN bio VARCHAR(160) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN location VARCHAR(30) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN website VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN avatar_url VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN banner_url VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN private BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,17 @@
+-- Media attachments: uploaded images, attached to tweets, garbage-collected when left unattached
+CREATE TABLE IF NOT EXISTS media (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    user_id INT NOT NULL,
+    tweet_id INT NULL DEFAULT NULL,
+    position TINYINT NOT NULL DEFAULT 0,
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes INT NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE SET NULL
+);
+CREATE INDEX idx_media_tweet ON media (tweet_id, position);
+CREATE INDEX idx_media_orphans ON media (tweet_id, created_at);
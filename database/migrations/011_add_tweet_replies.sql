@@ -0,0 +1,7 @@
+-- Replies and conversations
+-- Existing tweets all start their own conversation, which a NULL conversation_id means
+ALTER TABLE tweets ADD COLUMN in_reply_to_tweet_id INT NULL DEFAULT NULL;
+ALTER TABLE tweets ADD COLUMN conversation_id INT NULL DEFAULT NULL;
+ALTER TABLE tweets ADD CONSTRAINT fk_tweets_in_reply_to FOREIGN KEY (in_reply_to_tweet_id) REFERENCES tweets(id) ON DELETE SET NULL;
+CREATE INDEX idx_tweets_in_reply_to ON tweets (in_reply_to_tweet_id, id);
+CREATE INDEX idx_tweets_conversation ON tweets (conversation_id);
@@ -0,0 +1,16 @@
+-- Quote tweets and blocks
+-- quoted_tweet_id has no foreign key on purpose: it keeps pointing at a deleted tweet,
+-- so the quote can show a tombstone instead of silently becoming a plain tweet
+ALTER TABLE tweets ADD COLUMN quoted_tweet_id INT NULL DEFAULT NULL;
+CREATE INDEX idx_tweets_quoted ON tweets (quoted_tweet_id, id);
+
+CREATE TABLE IF NOT EXISTS blocks (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    blocker_id INT NOT NULL,
+    blocked_id INT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT UNIQUE(blocker_id, blocked_id)
+);
+CREATE INDEX idx_blocks_blocked ON blocks (blocked_id, blocker_id);
@@ -0,0 +1,18 @@
+-- Hashtags extracted from the content of tweets
+-- Tags are stored normalized (composed and lower-cased) and compared byte for byte,
+-- so the accent-insensitive default collation doesn't merge e.g. #café and #cafe
+-- Existing tweets are indexed with: go run ./cmd/index-hashtags
+CREATE TABLE IF NOT EXISTS hashtags (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    tag VARCHAR(100) CHARACTER SET utf8mb4 COLLATE utf8mb4_bin NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tweet_hashtags (
+    tweet_id INT NOT NULL,
+    hashtag_id INT NOT NULL,
+    PRIMARY KEY (tweet_id, hashtag_id),
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE CASCADE,
+    FOREIGN KEY (hashtag_id) REFERENCES hashtags(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_tweet_hashtags_hashtag ON tweet_hashtags (hashtag_id, tweet_id);
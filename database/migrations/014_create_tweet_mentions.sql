@@ -0,0 +1,13 @@
+-- Users mentioned in tweets, one row per "@username" naming an existing user
+-- Offsets are in characters (Unicode code points) of the content
+-- Existing tweets are indexed with: go run ./cmd/index-entities
+CREATE TABLE IF NOT EXISTS tweet_mentions (
+    tweet_id INT NOT NULL,
+    user_id INT NOT NULL,
+    start_offset SMALLINT NOT NULL,
+    end_offset SMALLINT NOT NULL,
+    PRIMARY KEY (tweet_id, start_offset),
+    FOREIGN KEY (tweet_id) REFERENCES tweets(id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+CREATE INDEX idx_tweet_mentions_user ON tweet_mentions (user_id, tweet_id);
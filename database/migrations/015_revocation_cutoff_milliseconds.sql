@@ -0,0 +1,3 @@
+-- Tokens now carry their issue time to the millisecond; the "log out everywhere" cut-off must be as precise,
+-- or a token issued in the same second right after a logout-all or password reset is rejected
+ALTER TABLE user_token_revocations MODIFY revoked_before TIMESTAMP(3) NOT NULL;
@@ -0,0 +1,3 @@
+-- The "private" setting only hides the profile details and follow lists from non-followers: tweets stay public.
+-- The column is renamed so nobody reads it as protected tweets
+ALTER TABLE users CHANGE private hide_profile_details BOOLEAN NOT NULL DEFAULT FALSE;
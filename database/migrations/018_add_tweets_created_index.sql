@@ -0,0 +1,2 @@
+-- Trends read the tweets of the last minutes by creation time
+CREATE INDEX idx_tweets_created ON tweets (created_at, id);
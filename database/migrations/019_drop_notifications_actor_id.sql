@@ -0,0 +1,5 @@
+-- notifications.actor_id only held the latest actor and was never read: notification_actors lists them all.
+-- Its ON DELETE CASCADE deleted a whole grouped notification, with every other actor, when the latest actor's
+-- account was purged. notifications_ibfk_2 is the name MySQL gave its foreign key (the second one of the table).
+ALTER TABLE notifications DROP FOREIGN KEY notifications_ibfk_2;
+ALTER TABLE notifications DROP COLUMN actor_id;
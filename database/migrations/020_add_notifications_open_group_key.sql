@@ -0,0 +1,14 @@
+-- Two events opening the same group at once could both create a notification: nothing kept one unread
+-- group per user and key. open_group_key is the group key until the notification is read (then NULL),
+-- and its unique key lets RecordNotification open the group with INSERT ... ON DUPLICATE KEY UPDATE.
+ALTER TABLE notifications ADD COLUMN open_group_key VARCHAR(64) NULL DEFAULT NULL AFTER group_key;
+
+-- Only the latest unread notification of each group stays open, in case duplicates were already created
+UPDATE notifications n
+JOIN (
+    SELECT MAX(id) AS id FROM notifications WHERE read_at IS NULL GROUP BY user_id, group_key
+) latest ON latest.id = n.id
+SET n.open_group_key = n.group_key;
+
+CREATE UNIQUE INDEX uq_notifications_open_group ON notifications (user_id, open_group_key);
+DROP INDEX idx_notifications_group ON notifications;
@@ -0,0 +1,99 @@
+// Package entities finds the parts of a tweet's text that mean something to the app: #hashtags and @mentions
+// Offsets are counted in Unicode code points (runes) of the text as stored, not bytes, so clients in any language can use them.
+package entities
+
+import (
+	"strings" // To lower-case tags
+	"unicode" // To classify the characters around and inside a tag
+
+	"golang.org/x/text/unicode/norm" // To compose accents, so "é" typed two ways is one tag
+)
+
+// MaxHashtagLength is the longest tag kept, in characters; longer ones are not hashtags
+const MaxHashtagLength = 100
+
+// Hashtag is a #hashtag found in a text
+type Hashtag struct {
+	Tag   string `json:"tag"`   // The normalized tag, without "#": composed (NFC) and lower-cased
+	Text  string `json:"text"`  // The tag as written, without "#"
+	Start int    `json:"start"` // Offset of the "#", in runes
+	End   int    `json:"end"`   // Offset just after the tag, in runes
+}
+
+// ExtractHashtags returns the hashtags of a text, in order
+// A hashtag is "#" (or the full-width "＃") followed by letters, marks, digits and underscores of any script,
+// with at least one letter, e.g. #golang, #Café, #東京, #2024_election. The "#" must not follow one of these
+// characters, an "&" (as in "&#39;") or another "#", so "a#b" and "##b" are not hashtags.
+func ExtractHashtags(text string) []Hashtag {
+	runes := []rune(text)
+	hashtags := []Hashtag{}
+
+	for i := 0; i < len(runes); i++ {
+		if !isHash(runes[i]) {
+			continue
+		}
+		if i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == '&' || isHash(runes[i-1])) {
+			continue
+		}
+
+		// Read the tag up to the first character that can't be part of it
+		end := i + 1
+		for end < len(runes) && isTagRune(runes[end]) {
+			end++
+		}
+		// The length and the letter are checked on the normalized tag, which is what gets stored
+		text := string(runes[i+1 : end])
+		if tag := NormalizeHashtag(text); tag != "" && (end == len(runes) || !isHash(runes[end])) {
+			hashtags = append(hashtags, Hashtag{Tag: tag, Text: text, Start: i, End: end})
+		}
+		i = end - 1 // Continue after the tag
+	}
+	return hashtags
+}
+
+// HashtagTags returns the distinct normalized tags of a list of hashtags, in order of first appearance
+func HashtagTags(hashtags []Hashtag) []string {
+	seen := map[string]bool{}
+	tags := []string{}
+	for _, hashtag := range hashtags {
+		if !seen[hashtag.Tag] {
+			seen[hashtag.Tag] = true
+			tags = append(tags, hashtag.Tag)
+		}
+	}
+	return tags
+}
+
+// NormalizeHashtag turns a tag as written (with or without "#") into the form it is stored and looked up in
+// It returns "" when the text isn't a valid tag. The checks run on the final, lower-cased form,
+// since that is what is stored and must fit the column.
+func NormalizeHashtag(text string) string {
+	text = strings.ToLower(norm.NFC.String(text))
+	text = strings.TrimLeftFunc(text, isHash)
+	count := 0
+	hasLetter := false
+	for _, r := range text {
+		if !isTagRune(r) {
+			return ""
+		}
+		if unicode.IsLetter(r) || unicode.IsMark(r) {
+			hasLetter = true
+		}
+		count++
+	}
+	if count == 0 || count > MaxHashtagLength || !hasLetter {
+		return ""
+	}
+	return text
+}
+
+// isHash reports whether r starts a hashtag
+func isHash(r rune) bool {
+	return r == '#' || r == '＃'
+}
+
+// isTagRune reports whether r can be part of a tag
+// Zero-width joiners are kept because some scripts (e.g. Persian, Hindi) need them inside words.
+func isTagRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsNumber(r) || r == '_' || r == '\u200c' || r == '\u200d'
+}
@@ -0,0 +1,113 @@
+package entities
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Hashtag
+	}{
+		{
+			name: "offsets in runes",
+			text: "héllo #Go!",
+			want: []Hashtag{{Tag: "go", Text: "Go", Start: 6, End: 9}},
+		},
+		{
+			name: "inside punctuation",
+			text: "(#golang),#gophers",
+			want: []Hashtag{{Tag: "golang", Text: "golang", Start: 1, End: 8}, {Tag: "gophers", Text: "gophers", Start: 10, End: 18}},
+		},
+		{
+			name: "html entities",
+			text: "it&#39;s here",
+			want: []Hashtag{},
+		},
+		{
+			name: "after a word or another hash",
+			text: "a#b ##x",
+			want: []Hashtag{},
+		},
+		{
+			name: "followed by a hash",
+			text: "#go# #",
+			want: []Hashtag{},
+		},
+		{
+			name: "full-width hash",
+			text: "＃東京 tower",
+			want: []Hashtag{{Tag: "東京", Text: "東京", Start: 0, End: 3}},
+		},
+		{
+			name: "composed accents",
+			text: "#Cafe\u0301",
+			want: []Hashtag{{Tag: "caf\u00e9", Text: "Cafe\u0301", Start: 0, End: 6}},
+		},
+		{
+			name: "zero-width non-joiner",
+			text: "#می\u200cخواهم",
+			want: []Hashtag{{Tag: "می\u200cخواهم", Text: "می\u200cخواهم", Start: 0, End: 9}},
+		},
+		{
+			name: "digits",
+			text: "#2024 #2024_election",
+			want: []Hashtag{{Tag: "2024_election", Text: "2024_election", Start: 6, End: 20}},
+		},
+		{
+			name: "length of the composed tag",
+			text: "#" + strings.Repeat("e\u0301", MaxHashtagLength) + " #" + strings.Repeat("a", MaxHashtagLength+1),
+			want: []Hashtag{{
+				Tag:   strings.Repeat("\u00e9", MaxHashtagLength),
+				Text:  strings.Repeat("e\u0301", MaxHashtagLength),
+				Start: 0,
+				End:   1 + 2*MaxHashtagLength,
+			}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExtractHashtags(test.text); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("ExtractHashtags(%q) = %+v, want %+v", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeHashtag(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{text: "GoLang", want: "golang"},
+		{text: "#Caf\u00e9", want: "caf\u00e9"},
+		{text: "＃東京", want: "東京"},
+		{text: "Cafe\u0301", want: "caf\u00e9"},
+		{text: "क्\u200dष", want: "क्\u200dष"},
+		{text: "2024_election", want: "2024_election"},
+		{text: "2024", want: ""},
+		{text: "go lang", want: ""},
+		{text: "go-lang", want: ""},
+		{text: "#", want: ""},
+		{text: "", want: ""},
+		{text: strings.Repeat("A", MaxHashtagLength), want: strings.Repeat("a", MaxHashtagLength)},
+		{text: strings.Repeat("a", MaxHashtagLength+1), want: ""},
+	}
+
+	for _, test := range tests {
+		if got := NormalizeHashtag(test.text); got != test.want {
+			t.Errorf("NormalizeHashtag(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestHashtagTags(t *testing.T) {
+	got := HashtagTags(ExtractHashtags("#Go #golang #GO #go"))
+	if want := []string{"go", "golang"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("HashtagTags = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"unicode" // To classify the characters around and inside a username
+)
+
+// MaxMentionLength is the longest username that can be mentioned, as allowed at registration
+const MaxMentionLength = 50
+
+// Mention is an @username found in a text
+// The username is only a candidate: whether a user goes by that name is checked against the users table.
+type Mention struct {
+	Username string `json:"username"` // The name as written, without "@"
+	Start    int    `json:"start"`    // Offset of the "@", in runes
+	End      int    `json:"end"`      // Offset just after the name, in runes
+}
+
+// ExtractMentions returns the @mentions of a text, in order
+// A mention is "@" (or the full-width "＠") followed by letters, digits and underscores of any script. The "@" must
+// not follow one of these characters or another "@", and the name must not be followed by "@", so e-mail addresses
+// such as "bob@example.com" and "@bob@example.com" are not mentions.
+func ExtractMentions(text string) []Mention {
+	runes := []rune(text)
+	mentions := []Mention{}
+
+	for i := 0; i < len(runes); i++ {
+		if !isAt(runes[i]) {
+			continue
+		}
+		if i > 0 && (isNameRune(runes[i-1]) || isAt(runes[i-1])) {
+			continue
+		}
+
+		// Read the name up to the first character that can't be part of it
+		end := i + 1
+		for end < len(runes) && isNameRune(runes[end]) {
+			end++
+		}
+		length := end - (i + 1)
+		if length > 0 && length <= MaxMentionLength && (end == len(runes) || !isAt(runes[end])) {
+			mentions = append(mentions, Mention{Username: string(runes[i+1 : end]), Start: i, End: end})
+		}
+		i = end - 1 // Continue after the name
+	}
+	return mentions
+}
+
+// IsValidUsername reports whether a username can be mentioned: 1 to MaxMentionLength letters, digits and underscores
+// Registration enforces it, so "@bob.smith" can never be mistaken for a mention of a user named "bob.smith".
+func IsValidUsername(username string) bool {
+	count := 0
+	for _, r := range username {
+		if !isNameRune(r) {
+			return false
+		}
+		count++
+	}
+	return count > 0 && count <= MaxMentionLength
+}
+
+// isAt reports whether r starts a mention
+func isAt(r rune) bool {
+	return r == '@' || r == '＠'
+}
+
+// isNameRune reports whether r can be part of a mentioned username
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsNumber(r) || r == '_'
+}
@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Mention
+	}{
+		{
+			name: "offsets in runes",
+			text: "héllo @bob!",
+			want: []Mention{{Username: "bob", Start: 6, End: 10}},
+		},
+		{
+			name: "inside punctuation",
+			text: "(@bob),@alice",
+			want: []Mention{{Username: "bob", Start: 1, End: 5}, {Username: "alice", Start: 7, End: 13}},
+		},
+		{
+			name: "e-mail addresses",
+			text: "bob@example.com @bob@example.com",
+			want: []Mention{},
+		},
+		{
+			name: "after another at sign",
+			text: "@@bob @ alone",
+			want: []Mention{},
+		},
+		{
+			name: "up to the first character a username can't have",
+			text: "@bob.smith",
+			want: []Mention{{Username: "bob", Start: 0, End: 4}},
+		},
+		{
+			name: "full-width at sign",
+			text: "＠ken and @東京_42",
+			want: []Mention{{Username: "ken", Start: 0, End: 4}, {Username: "東京_42", Start: 9, End: 15}},
+		},
+		{
+			name: "length",
+			text: "@" + strings.Repeat("a", MaxMentionLength) + " @" + strings.Repeat("b", MaxMentionLength+1),
+			want: []Mention{{Username: strings.Repeat("a", MaxMentionLength), Start: 0, End: 1 + MaxMentionLength}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := ExtractMentions(test.text); !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("ExtractMentions(%q) = %+v, want %+v", test.text, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsValidUsername(t *testing.T) {
+	tests := []struct {
+		username string
+		want     bool
+	}{
+		{username: "bob_42", want: true},
+		{username: "東京", want: true},
+		{username: "José", want: true},
+		{username: "bob.smith", want: false},
+		{username: "bob smith", want: false},
+		{username: "bob@example.com", want: false},
+		{username: "", want: false},
+		{username: strings.Repeat("a", MaxMentionLength), want: true},
+		{username: strings.Repeat("a", MaxMentionLength+1), want: false},
+	}
+
+	for _, test := range tests {
+		if got := IsValidUsername(test.username); got != test.want {
+			t.Errorf("IsValidUsername(%q) = %v, want %v", test.username, got, test.want)
+		}
+	}
+}
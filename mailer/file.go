@@ -0,0 +1,41 @@
+package mailer
+
+import (
+	"encoding/json" // To write each message as one JSON line
+	"os"            // To open and append to the outbox file
+	"sync"          // To make sure two requests don't write to the file at the same time
+	"time"          // To record when the message was "sent"
+)
+
+// FileSender is a Sender that appends every message to a file instead of delivering it
+// This is handy during development: you can open the file and copy the reset link or token from it
+type FileSender struct {
+	path string     // The path of the outbox file
+	mu   sync.Mutex // Protects concurrent writes to the file
+}
+
+// NewFileSender creates a FileSender that writes messages to the given path
+func NewFileSender(path string) *FileSender {
+	return &FileSender{path: path}
+}
+
+// Send appends the message to the outbox file as a single JSON line
+func (s *FileSender) Send(msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Open the file in append mode and create it if it doesn't exist yet
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// Store the time together with the message so the outbox reads like a log
+	entry := struct {
+		SentAt time.Time `json:"sent_at"`
+		Message
+	}{SentAt: time.Now(), Message: msg}
+
+	return json.NewEncoder(file).Encode(entry)
+}
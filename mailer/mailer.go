@@ -0,0 +1,16 @@
+package mailer
+
+// Message represents a single email that the application wants to deliver
+// It only holds the fields we actually need: who it goes to, the subject and the plain text body
+type Message struct {
+	To      string `json:"to"`      // The email address of the recipient
+	Subject string `json:"subject"` // The subject line of the email
+	Body    string `json:"body"`    // The plain text content of the email
+}
+
+// Sender is the interface every email backend must implement
+// Controllers only talk to this interface, so we can swap the real delivery (SMTP, an API, a file, memory)
+// without touching the code that decides when an email should be sent
+type Sender interface {
+	Send(msg Message) error
+}
@@ -0,0 +1,43 @@
+package mailer
+
+import "sync" // To protect the list of messages from concurrent access
+
+// MemorySender is a Sender that keeps every message in memory
+// It is meant for tests, where we want to inspect what would have been emailed
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+// NewMemorySender creates an empty MemorySender
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// Send stores the message in memory
+func (s *MemorySender) Send(msg Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.messages = append(s.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of all the messages sent so far
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	messages := make([]Message, len(s.messages))
+	copy(messages, s.messages)
+	return messages
+}
+
+// Last returns the most recently sent message, or nil if nothing was sent
+func (s *MemorySender) Last() *Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.messages) == 0 {
+		return nil
+	}
+	msg := s.messages[len(s.messages)-1]
+	return &msg
+}
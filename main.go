@@ -2,24 +2,55 @@ package main
 
 import (
 	"GO-X/controllers" // Import the controllers package where the database logic is handled
+	"GO-X/mailer"      // Import the mailer package used to send emails (e.g., password resets)
+	"GO-X/middleware"  // Import the middleware package to configure the rate limiters
+	"GO-X/models"      // Import the models package to purge deleted accounts
+	"GO-X/realtime"    // Import the realtime package to push events to connected clients
 	"GO-X/routes"      // Import the routes package where the HTTP routes are defined
+	"GO-X/storage"     // Import the storage package where uploaded media are kept
+	"GO-X/timeline"    // Import the timeline package to materialize home timelines
+	"GO-X/trends"      // Import the trends package to compute trending topics
+	"GO-X/utils"       // Import the utils package to configure how tokens are revoked
+	"context"          // Import the context package for the background storage calls
 	"database/sql"     // Import the database/sql package to interact with the SQL database
 	"log"              // Import the log package for logging errors and info
+	"os"               // Import the os package to read configuration from the environment
+	"strconv"          // Import the strconv package to parse numbers from the environment
+	"strings"          // Import the strings package to split the list of trusted proxies
+	"time"             // Import the time package to schedule background jobs
 
 	_ "github.com/go-sql-driver/mysql" // Blank import to initialize the MySQL driver (this allows us to interact with MySQL databases)
 	"github.com/gofiber/fiber/v2"      // Import the Fiber web framework for building the web server
+	"github.com/redis/go-redis/v9"     // Import the Redis client to share rate limit counters between instances
 )
 
 func main() {
 	// 1. Create a new Fiber app. This app will handle incoming HTTP requests and responses.
-	app := fiber.New()
+	// The body limit leaves room for media uploads (controllers.MaxMediaSize) and the multipart form around them.
+	// Behind a reverse proxy, TRUSTED_PROXIES lists its addresses (e.g. "10.0.0.1,10.0.1.0/24") so the client IP,
+	// which rate limits are keyed on, is read from PROXY_HEADER ("X-Forwarded-For" by default) instead of being
+	// the proxy's. The proxy must overwrite that header, not append to it, or clients could choose their own IP.
+	config := fiber.Config{
+		BodyLimit: controllers.MaxMediaSize + 1024*1024,
+	}
+	if proxies := os.Getenv("TRUSTED_PROXIES"); proxies != "" {
+		config.EnableTrustedProxyCheck = true
+		config.TrustedProxies = strings.Split(proxies, ",")
+		config.ProxyHeader = os.Getenv("PROXY_HEADER")
+		if config.ProxyHeader == "" {
+			config.ProxyHeader = fiber.HeaderXForwardedFor
+		}
+		config.EnableIPValidation = true // Only take a well-formed IP address from the header
+	}
+	app := fiber.New(config)
 
 	// 2. Set up the MySQL database connection.
 	// We are defining the Data Source Name (DSN) here, which contains the necessary credentials
 	// to connect to our MySQL database (change these values to match your MySQL setup).
-	dsn := "root:@tcp(localhost:3306)/GO-X" // Change this string to your MySQL username, password, and database name
-	db, err := sql.Open("mysql", dsn)       // Attempt to open the database connection using the MySQL driver
-	if err != nil {                         // If there's an error opening the database, we log and exit
+	// parseTime=true makes the driver return TIMESTAMP columns as time.Time values.
+	dsn := "root:@tcp(localhost:3306)/GO-X?parseTime=true" // Change this string to your MySQL username, password, and database name
+	db, err := sql.Open("mysql", dsn)                      // Attempt to open the database connection using the MySQL driver
+	if err != nil {                                        // If there's an error opening the database, we log and exit
 		log.Fatal("Error opening the database: ", err)
 	}
 	defer db.Close() // Ensures that the database connection is closed when the function exits
@@ -38,6 +69,107 @@ func main() {
 	// This makes sure that the controllers have access to the database.
 	controllers.SetDB(db)
 
+	// Emails are written to a local outbox file for now; replace this with a real Sender to deliver them.
+	controllers.SetMailer(mailer.NewFileSender("mail_outbox.log"))
+
+	// Links in emails (e.g. email verification) point to PUBLIC_URL, by default http://localhost:8000.
+	if publicURL := os.Getenv("PUBLIC_URL"); publicURL != "" {
+		controllers.SetPublicURL(publicURL)
+	}
+
+	// Load the JWT signing keys from the environment (see utils.LoadKeySetFromEnv for the variables).
+	keys, err := utils.LoadKeySetFromEnv()
+	if err != nil {
+		log.Fatal("Error loading JWT signing keys: ", err)
+	}
+	utils.SetKeySet(keys)
+
+	// Revoked tokens are stored in the database so logouts are shared by every server instance.
+	utils.SetRevocationStore(utils.NewSQLRevocationStore(db))
+
+	// Deleted accounts can be restored during this grace period (e.g. "720h"); by default they are removed right away.
+	if value := os.Getenv("ACCOUNT_DELETION_GRACE_PERIOD"); value != "" {
+		gracePeriod, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatal("Invalid ACCOUNT_DELETION_GRACE_PERIOD: ", err)
+		}
+		controllers.SetAccountDeletionGracePeriod(gracePeriod)
+		go purgeDeletedUsers(db, gracePeriod)
+	}
+
+	// Failed logins are throttled per account and per IP (see controllers/Lockout.go); old records are purged hourly.
+	// Locked accounts unlock on their own, or right away with: go run ./cmd/unlock-account -user <username>
+	go purgeLoginThrottles(db, controllers.LoginLockoutResetAfter())
+
+	// Home timelines are materialized with fan-out-on-write. Accounts with more followers than
+	// TIMELINE_CELEBRITY_THRESHOLD are merged at read time instead. TIMELINE_STORE picks "sql" (default) or "memory".
+	// Timelines that were never materialized are built on their first read. Each one keeps its newest
+	// TIMELINE_MAX_ENTRIES entries, which is also how many are written when one is rebuilt.
+	timelineMaxEntries := envInt("TIMELINE_MAX_ENTRIES", 800)
+	var timelineStore timeline.Store = timeline.NewSQLStore(db, timelineMaxEntries)
+	if os.Getenv("TIMELINE_STORE") == "memory" {
+		timelineStore = timeline.NewMemoryStore(timelineMaxEntries)
+	}
+	timelineService := timeline.NewService(db, timelineStore, envInt("TIMELINE_CELEBRITY_THRESHOLD", 10000))
+	timelineService.RebuildSize = timelineMaxEntries
+	controllers.SetTimeline(timelineService)
+
+	// Real-time events are pushed to connected clients through a hub. Every connection buffers up to
+	// REALTIME_BUFFER_SIZE events; when a client falls behind, REALTIME_SLOW_CLIENT_POLICY decides whether
+	// the oldest events are dropped ("drop", the default) or the client is disconnected ("disconnect").
+	// The last REALTIME_REPLAY_SIZE events of each user are kept so event streams can resume after a reconnect.
+	slowClientPolicy := realtime.DropOldest
+	if os.Getenv("REALTIME_SLOW_CLIENT_POLICY") == "disconnect" {
+		slowClientPolicy = realtime.Disconnect
+	}
+	// Those events are kept for REALTIME_REPLAY_TTL after the user's last connection closed; nothing is kept
+	// for users who aren't connected.
+	hub := realtime.NewHub(envInt("REALTIME_BUFFER_SIZE", 64), envInt("REALTIME_REPLAY_SIZE", 100), slowClientPolicy)
+	hub.ReplayTTL = envDuration("REALTIME_REPLAY_TTL", 5*time.Minute)
+	controllers.SetHub(hub)
+	go pruneHub(hub)
+
+	// Rate limit counters are kept in memory, unless RATE_LIMIT_REDIS_URL (e.g. "redis://localhost:6379/0")
+	// points to a Redis server, which lets every server instance share them.
+	if redisURL := os.Getenv("RATE_LIMIT_REDIS_URL"); redisURL != "" {
+		options, err := redis.ParseURL(redisURL)
+		if err != nil {
+			log.Fatal("Invalid RATE_LIMIT_REDIS_URL: ", err)
+		}
+		redisClient := redis.NewClient(options)
+		defer redisClient.Close()
+		middleware.SetRateLimitStore(middleware.NewRedisRateLimitStore(redisClient))
+	}
+
+	// Uploaded media are stored on the local disk under MEDIA_DIR (default "media"), or in an S3-compatible
+	// bucket when MEDIA_STORE is "s3" (S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY;
+	// e.g. a local MinIO at http://localhost:9000). Media never attached to a tweet are deleted after MEDIA_ORPHAN_TTL (default "24h").
+	mediaStore, err := newMediaStore()
+	if err != nil {
+		log.Fatal("Error setting up media storage: ", err)
+	}
+	controllers.SetMediaStore(mediaStore)
+	orphanTTL := 24 * time.Hour
+	if value := os.Getenv("MEDIA_ORPHAN_TTL"); value != "" {
+		orphanTTL, err = time.ParseDuration(value)
+		if err != nil {
+			log.Fatal("Invalid MEDIA_ORPHAN_TTL: ", err)
+		}
+	}
+	controllers.SetMediaOrphanTTL(orphanTTL)
+	go purgeOrphanedMedia(db, mediaStore, orphanTTL)
+
+	// Trending hashtags and terms compare the last TRENDS_WINDOW (default "1h") with the TRENDS_BASELINE
+	// before it (default "24h"). They are recomputed every TRENDS_REFRESH_INTERVAL (default "1m") and
+	// GET /trends serves the last result. A topic needs TRENDS_MIN_COUNT tweets in the window (default 5).
+	trendTracker := trends.NewTracker(db, trends.NewEngine(trends.Config{
+		Window:   envDuration("TRENDS_WINDOW", time.Hour),
+		Baseline: envDuration("TRENDS_BASELINE", 24*time.Hour),
+		MinCount: envInt("TRENDS_MIN_COUNT", 5),
+	}))
+	controllers.SetTrends(trendTracker)
+	go refreshTrends(trendTracker, envDuration("TRENDS_REFRESH_INTERVAL", time.Minute))
+
 	// 6. Next, we set up all the routes for the web application using the routes package.
 	// Routes define how the app should handle incoming requests (like what happens when someone visits a URL).
 	routes.SetupRoutes(app, db)
@@ -49,3 +181,129 @@ func main() {
 		log.Fatal("Error starting the server: ", err)
 	}
 }
+
+// purgeDeletedUsers permanently removes soft-deleted accounts once their grace period is over
+// It runs in the background for as long as the server is running
+func purgeDeletedUsers(db *sql.DB, gracePeriod time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		purged, err := models.PurgeDeletedUsers(db, time.Now().Add(-gracePeriod))
+		if err != nil {
+			log.Println("Error purging deleted users:", err)
+			continue
+		}
+		if purged > 0 {
+			log.Printf("Purged %d deleted account(s)", purged)
+		}
+	}
+}
+
+// purgeLoginThrottles removes the failed login records that are too old to matter
+// It runs in the background for as long as the server is running
+func purgeLoginThrottles(db *sql.DB, resetAfter time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		if _, err := models.PurgeLoginThrottles(db, time.Now().Add(-resetAfter)); err != nil {
+			log.Println("Error purging failed logins:", err)
+		}
+	}
+}
+
+// newMediaStore creates the storage backend for uploaded media from the environment
+func newMediaStore() (storage.Store, error) {
+	if os.Getenv("MEDIA_STORE") == "s3" {
+		return storage.NewS3Store(storage.S3Config{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		}), nil
+	}
+	dir := os.Getenv("MEDIA_DIR")
+	if dir == "" {
+		dir = "media"
+	}
+	return storage.NewLocalStore(dir)
+}
+
+// purgeOrphanedMedia deletes the media that were never attached to a tweet (or whose tweet was deleted)
+// It runs in the background for as long as the server is running
+func purgeOrphanedMedia(db *sql.DB, store storage.Store, ttl time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		orphans, err := models.GetOrphanedMedia(db, time.Now().Add(-ttl), 1000)
+		if err != nil {
+			log.Println("Error listing orphaned media:", err)
+			continue
+		}
+		purged := 0
+		for _, media := range orphans {
+			// Claim the row first: a tweet posted in the meantime may have attached the media, and must not
+			// end up pointing at a deleted file. A file whose deletion fails is left behind and logged.
+			claimed, err := models.DeleteOrphanedMedia(db, media.ID)
+			if err != nil {
+				log.Println("Error deleting orphaned media:", err)
+				continue
+			}
+			if !claimed {
+				continue // Attached to a tweet after all
+			}
+			if err := store.Delete(context.Background(), media.StorageKey); err != nil {
+				log.Printf("Error deleting orphaned media file %q: %v", media.StorageKey, err)
+				continue
+			}
+			purged++
+		}
+		if purged > 0 {
+			log.Printf("Purged %d orphaned media", purged)
+		}
+	}
+}
+
+// refreshTrends recomputes the trends periodically
+// It runs in the background for as long as the server is running
+func refreshTrends(tracker *trends.Tracker, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		if err := tracker.Refresh(); err != nil {
+			log.Println("Error refreshing trends:", err)
+		}
+	}
+}
+
+// pruneHub frees the replay buffers of users who disconnected more than ReplayTTL ago
+func pruneHub(hub *realtime.Hub) {
+	ticker := time.NewTicker(max(hub.ReplayTTL, time.Minute))
+	defer ticker.Stop()
+	for range ticker.C {
+		hub.Prune()
+	}
+}
+
+// envDuration reads a duration (e.g. "90s", "1h") from the environment, falling back to def when it is missing
+// An invalid value stops the server, as a typo would otherwise go unnoticed
+func envDuration(name string, def time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return def
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Fatalf("Invalid %s: %q", name, value)
+	}
+	return duration
+}
+
+// envInt reads an integer from the environment, falling back to def when it is missing or invalid
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
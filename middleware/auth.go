@@ -5,7 +5,8 @@ import (
 	"log"        // For logging errors or other information
 	"strings"    // For manipulating strings (e.g., trimming prefixes)
 
-	"github.com/gofiber/fiber/v2" // Import the Fiber web framework
+	"github.com/gofiber/fiber/v2"  // Import the Fiber web framework
+	"github.com/golang-jwt/jwt/v4" // Import the JWT library for the claims type
 )
 
 // ProtectRoute is a middleware function that protects routes by verifying the JWT token
@@ -35,22 +36,59 @@ func ProtectRoute(c *fiber.Ctx) error {
 	// Extract the actual JWT token by removing the "Bearer " prefix
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
+	// Validate the token and make sure it hasn't been revoked
+	claims, err := checkToken(c, tokenString)
+	if claims == nil {
+		return err
+	}
+
+	// If the token is valid, store the claims (user info and other data) in the Fiber context
+	// This allows access to the claims in any handler that follows
+	c.Locals("claims", claims)
+
+	// Proceed with the request by calling the next handler in the chain
+	return c.Next()
+}
+
+// checkToken validates a JWT and checks it against the revocation store
+// When the token can't be used it writes the error response and returns nil claims
+func checkToken(c *fiber.Ctx, tokenString string) (jwt.MapClaims, error) {
 	// Call the utility function to validate the JWT token
 	// This function checks if the token is valid and hasn't expired
 	claims, err := utils.ValidateJWT(tokenString)
 	if err != nil {
 		// If there was an error (e.g., the token is invalid or expired), log the error and return a 401 Unauthorized response
 		log.Println("Error validating token:", err)
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"status":  "error",
 			"message": "Invalid or expired token",
 		})
 	}
 
-	// If the token is valid, store the claims (user info and other data) in the Fiber context
-	// This allows access to the claims in any handler that follows
-	c.Locals("claims", claims)
+	// Only access tokens open the API; e.g. a token issued before the 2FA code was checked must not
+	if utils.TokenUse(claims) != utils.TokenUseAccess {
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+	}
 
-	// Proceed with the request by calling the next handler in the chain
-	return c.Next()
+	// A valid token may still have been revoked (logout, "log out everywhere", deleted account...)
+	// so every request is checked against the revocation store
+	revoked, err := utils.IsTokenRevoked(claims)
+	if err != nil {
+		log.Println("Error checking token revocation:", err)
+		return nil, c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Internal server error",
+		})
+	}
+	if revoked {
+		return nil, c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid or expired token",
+		})
+	}
+
+	return claims, nil
 }
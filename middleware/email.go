@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"GO-X/models"  // Import the models package to read the verification status of users
+	"database/sql" // Import the sql package to interact with the database
+	"log"          // For logging errors or other information
+
+	"github.com/gofiber/fiber/v2"  // Import the Fiber web framework
+	"github.com/golang-jwt/jwt/v4" // Import the JWT library for the claims type
+)
+
+// RequireVerifiedEmail returns a middleware that only lets users with a verified email address through
+// It must come after ProtectRoute. The status is read from the database on every request,
+// so a user who just opened the verification link doesn't need a new token.
+func RequireVerifiedEmail(db *sql.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		claims, _ := c.Locals("claims").(jwt.MapClaims)
+		username, _ := claims["username"].(string)
+
+		verified, err := models.IsEmailVerified(db, username)
+		if err != nil {
+			log.Println("Error checking email verification:", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Internal server error",
+			})
+		}
+		if !verified {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Verify your email address first",
+			})
+		}
+		return c.Next()
+	}
+}
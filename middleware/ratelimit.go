@@ -0,0 +1,221 @@
+package middleware
+
+import (
+	"context" // The stores receive the request context, so a slow backend can be abandoned
+	"log"     // For logging errors or other information
+	"math"    // To round the header values up to whole seconds
+	"strconv" // To write the numeric headers
+	"strings" // To join the parts of composite keys
+	"time"    // To express rate limit windows
+
+	"github.com/gofiber/fiber/v2"  // Import the Fiber web framework
+	"github.com/golang-jwt/jwt/v4" // Import the JWT library for the claims type
+)
+
+// RateLimitAlgorithm selects how requests are counted
+type RateLimitAlgorithm int
+
+const (
+	// TokenBucket allows bursts of up to Requests requests, then refills at Requests per Window
+	TokenBucket RateLimitAlgorithm = iota
+	// SlidingWindow allows at most Requests requests in any Window, estimated from the current and previous windows
+	SlidingWindow
+)
+
+// Limit describes how many requests a key may make
+type Limit struct {
+	Algorithm RateLimitAlgorithm
+	Requests  int           // Bucket capacity, or requests allowed per window
+	Window    time.Duration // Time it takes to refill the whole bucket, or length of the window
+}
+
+// RateLimitResult is the outcome of one rate limit check
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int           // Requests that can still be made right now
+	Reset      time.Duration // Time until the quota is fully available again
+	RetryAfter time.Duration // When not allowed, how long to wait before the next request can succeed
+}
+
+// RateLimitStore keeps the counters of the rate limiter
+// Allow records one request for key and reports whether it fits in the limit.
+// now is passed in rather than read from the clock, so stores can be tested with a fake clock.
+type RateLimitStore interface {
+	Allow(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error)
+}
+
+// KeyFunc returns the key a request is counted under
+// An empty key means the request is not rate limited
+type KeyFunc func(c *fiber.Ctx) string
+
+// KeyByIP counts requests per client IP address
+// Behind a reverse proxy, c.IP() is the proxy's address (so every client would share one bucket) unless
+// the app trusts the proxy and reads the client address from its header: see TRUSTED_PROXIES in main.go.
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// KeyByUser counts requests per authenticated user, from the claims stored by ProtectRoute
+// Requests without claims fall back to the client IP address
+func KeyByUser(c *fiber.Ctx) string {
+	if claims, ok := c.Locals("claims").(jwt.MapClaims); ok {
+		if username, ok := claims["username"].(string); ok && username != "" {
+			return "user:" + username
+		}
+	}
+	return KeyByIP(c)
+}
+
+// KeyByRoute counts requests per route, whoever makes them
+// Combine it with KeyByIP or KeyByUser (see CombineKeys) to limit each client on each route separately
+func KeyByRoute(c *fiber.Ctx) string {
+	return "route:" + c.Method() + " " + c.Route().Path
+}
+
+// CombineKeys builds a key out of several keys, e.g. CombineKeys(KeyByRoute, KeyByIP)
+func CombineKeys(keys ...KeyFunc) KeyFunc {
+	return func(c *fiber.Ctx) string {
+		parts := make([]string, 0, len(keys))
+		for _, key := range keys {
+			part := key(c)
+			if part == "" {
+				return ""
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, "|")
+	}
+}
+
+// RateLimitConfig configures one rate limiter
+type RateLimitConfig struct {
+	Name  string         // Namespace of the counters, so two limiters never share a key
+	Limit Limit          // How many requests are allowed
+	Key   KeyFunc        // What requests are counted under; defaults to KeyByIP
+	Store RateLimitStore // Where the counters live; defaults to the store set with SetRateLimitStore
+}
+
+var rateLimitStore RateLimitStore = NewMemoryRateLimitStore() // Default store of the rate limiters
+
+// SetRateLimitStore sets the store used by the rate limiters that don't have their own
+// It must be called before the routes are set up. The in-memory default only works with a single server instance.
+func SetRateLimitStore(store RateLimitStore) {
+	rateLimitStore = store
+}
+
+// RateLimit returns a middleware that rejects requests over the limit with 429 Too Many Requests
+// Every response carries the RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset and RateLimit-Policy headers,
+// and rejected responses also carry Retry-After. When the store fails, requests are let through:
+// an outage of the counters must not take the whole API down.
+// Use it after ProtectRoute when keying by user, so the claims are available.
+func RateLimit(config RateLimitConfig) fiber.Handler {
+	if config.Limit.Requests < 1 || config.Limit.Window < time.Second {
+		panic("rate limit " + config.Name + ": needs at least one request per window of at least a second")
+	}
+	if config.Key == nil {
+		config.Key = KeyByIP
+	}
+	policy := strconv.Itoa(config.Limit.Requests) + ";w=" + strconv.Itoa(int(config.Limit.Window/time.Second))
+
+	return func(c *fiber.Ctx) error {
+		key := config.Key(c)
+		if key == "" {
+			return c.Next()
+		}
+
+		store := config.Store
+		if store == nil {
+			store = rateLimitStore
+		}
+
+		result, err := store.Allow(c.Context(), config.Name+":"+key, config.Limit, time.Now())
+		if err != nil {
+			log.Println("Error checking rate limit:", err)
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(config.Limit.Requests))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		c.Set("RateLimit-Policy", policy)
+
+		if !result.Allowed {
+			c.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Too many requests, please try again later",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds, as the headers require
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+// refillRate returns how many tokens a bucket gains per second
+func (l Limit) refillRate() float64 {
+	return float64(l.Requests) / l.Window.Seconds()
+}
+
+// tokenBucketResult builds the result of a token bucket check
+// tokens is what is left in the bucket after the request was (or wasn't) taken out of it
+func tokenBucketResult(limit Limit, allowed bool, tokens float64) RateLimitResult {
+	rate := limit.refillRate()
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Requests) - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+// slidingWindowEstimate returns how many requests were made during the last window
+// The requests of the previous window are weighted by how much of it still overlaps the sliding window
+func slidingWindowEstimate(previous int, current int, elapsed time.Duration, window time.Duration) float64 {
+	return float64(previous)*(1-float64(elapsed)/float64(window)) + float64(current)
+}
+
+// slidingWindowResult builds the result of a sliding window check
+// previous and current are the counts after the request was (or wasn't) recorded,
+// and elapsed is the time since the current window started
+func slidingWindowResult(limit Limit, allowed bool, previous int, current int, elapsed time.Duration) RateLimitResult {
+	window := limit.Window
+	estimate := slidingWindowEstimate(previous, current, elapsed, window)
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Remaining: int(math.Max(0, math.Floor(float64(limit.Requests)-estimate))),
+		Reset:     window - elapsed,
+	}
+	if allowed {
+		return result
+	}
+
+	// Find when the estimate drops enough for one more request: first while the previous window
+	// still fades out, otherwise in the next window, where the current count becomes the previous one
+	room := float64(limit.Requests - 1 - current)
+	if room >= 0 && previous > 0 {
+		fraction := 1 - room/float64(previous)
+		result.RetryAfter = time.Duration(fraction*float64(window)) - elapsed
+	} else {
+		fraction := 1 - float64(limit.Requests-1)/float64(current)
+		result.RetryAfter = window - elapsed + time.Duration(math.Max(0, fraction)*float64(window))
+	}
+	if result.RetryAfter < 0 {
+		result.RetryAfter = 0
+	}
+	return result
+}
+
+// windowStart returns when the fixed window containing now started
+func windowStart(now time.Time, window time.Duration) time.Time {
+	return now.Truncate(window)
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"context" // To implement the RateLimitStore interface
+	"math"    // To cap the refilled tokens
+	"sync"    // To protect the counters from concurrent access
+	"time"    // To measure refills and windows
+)
+
+// rateLimitSweepInterval is how often expired counters are removed from a MemoryRateLimitStore
+const rateLimitSweepInterval = time.Minute
+
+// rateLimitEntry holds the counters of one key
+// Token buckets use tokens and updated; sliding windows use window, previous and current.
+type rateLimitEntry struct {
+	tokens    float64
+	updated   time.Time
+	window    time.Time // Start of the current window
+	previous  int
+	current   int
+	expiresAt time.Time // After this the entry holds no information and can be dropped
+}
+
+// MemoryRateLimitStore keeps rate limit counters in memory
+// It is only correct with a single server instance; use a RedisRateLimitStore otherwise.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore creates an empty in-memory store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{entries: make(map[string]*rateLimitEntry)}
+}
+
+// Allow records one request for key and reports whether it fits in the limit
+func (s *MemoryRateLimitStore) Allow(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &rateLimitEntry{tokens: float64(limit.Requests), updated: now, window: windowStart(now, limit.Window)}
+		s.entries[key] = entry
+	}
+
+	if limit.Algorithm == SlidingWindow {
+		return s.slidingWindow(entry, limit, now), nil
+	}
+	return s.tokenBucket(entry, limit, now), nil
+}
+
+// tokenBucket refills the bucket for the time elapsed since the last request, then takes one token out of it
+func (s *MemoryRateLimitStore) tokenBucket(entry *rateLimitEntry, limit Limit, now time.Time) RateLimitResult {
+	if elapsed := now.Sub(entry.updated); elapsed > 0 {
+		entry.tokens = math.Min(float64(limit.Requests), entry.tokens+elapsed.Seconds()*limit.refillRate())
+		entry.updated = now
+	}
+
+	allowed := entry.tokens >= 1
+	if allowed {
+		entry.tokens--
+	}
+	result := tokenBucketResult(limit, allowed, entry.tokens)
+	entry.expiresAt = now.Add(result.Reset) // Once full again, the bucket is the same as a new one
+	return result
+}
+
+// slidingWindow moves the windows forward if needed, then counts the request if it fits
+func (s *MemoryRateLimitStore) slidingWindow(entry *rateLimitEntry, limit Limit, now time.Time) RateLimitResult {
+	start := windowStart(now, limit.Window)
+	switch {
+	case start.Equal(entry.window.Add(limit.Window)):
+		entry.previous, entry.current = entry.current, 0
+	case start.After(entry.window):
+		entry.previous, entry.current = 0, 0 // More than a whole window went by
+	}
+	entry.window = start
+
+	elapsed := now.Sub(start)
+	allowed := slidingWindowEstimate(entry.previous, entry.current, elapsed, limit.Window)+1 <= float64(limit.Requests)
+	if allowed {
+		entry.current++
+	}
+	entry.expiresAt = start.Add(2 * limit.Window) // The current count matters until the end of the next window
+	return slidingWindowResult(limit, allowed, entry.previous, entry.current, elapsed)
+}
+
+// sweep drops the entries that expired, at most once per rateLimitSweepInterval
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
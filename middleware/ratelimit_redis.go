@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"context" // To implement the RateLimitStore interface
+	"fmt"     // To build the window keys
+	"strconv" // To read the token count returned by the script
+	"time"    // To measure refills and windows
+
+	"github.com/redis/go-redis/v9" // Redis client, used for the shared rate limit counters
+)
+
+// tokenBucketScript refills and takes from a token bucket atomically
+// KEYS[1] is the bucket; ARGV are the capacity, the refill rate in tokens per millisecond and the current time
+// in milliseconds. It returns whether the request is allowed and the tokens left, as a string so the fraction
+// isn't lost (Redis truncates Lua numbers to integers).
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(state[1])
+local updated = tonumber(state[2])
+if tokens == nil or updated == nil then
+	tokens = capacity
+	updated = now
+end
+if now > updated then
+	tokens = math.min(capacity, tokens + (now - updated) * rate)
+	updated = now
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', updated)
+redis.call('PEXPIRE', KEYS[1], math.ceil((capacity - tokens) / rate) + 1000)
+return {allowed, tostring(tokens)}
+`)
+
+// slidingWindowScript counts a request in the current window if the sliding estimate allows it
+// KEYS[1] and KEYS[2] are the counters of the current and previous windows; ARGV are the limit, the time
+// elapsed in the current window and the window length, both in milliseconds.
+// It returns whether the request is allowed and the previous and current counts.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local elapsed = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+
+local allowed = 0
+if previous * (1 - elapsed / window) + current + 1 <= limit then
+	current = redis.call('INCR', KEYS[1])
+	redis.call('PEXPIRE', KEYS[1], window * 2)
+	allowed = 1
+end
+return {allowed, previous, current}
+`)
+
+// RedisRateLimitStore keeps rate limit counters in Redis, so every server instance shares them
+// Each check is a single Lua script, which makes it atomic. It works with any server speaking the
+// Redis protocol and supporting scripts.
+type RedisRateLimitStore struct {
+	client redis.Scripter
+	prefix string
+}
+
+// NewRedisRateLimitStore creates a store using the given client (e.g. a *redis.Client)
+// Every key is prefixed with "ratelimit:"
+func NewRedisRateLimitStore(client redis.Scripter) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client, prefix: "ratelimit:"}
+}
+
+// Allow records one request for key and reports whether it fits in the limit
+func (s *RedisRateLimitStore) Allow(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	// The braces make Redis Cluster keep every key of a limiter on the same node, as scripts require
+	key = s.prefix + "{" + key + "}"
+
+	if limit.Algorithm == SlidingWindow {
+		return s.slidingWindow(ctx, key, limit, now)
+	}
+	return s.tokenBucket(ctx, key, limit, now)
+}
+
+// tokenBucket runs tokenBucketScript
+func (s *RedisRateLimitStore) tokenBucket(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	rate := limit.refillRate() / 1000 // Tokens per millisecond
+	values, err := tokenBucketScript.Run(ctx, s.client, []string{key}, limit.Requests, rate, now.UnixMilli()).Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(values) != 2 {
+		return RateLimitResult{}, fmt.Errorf("unexpected token bucket reply: %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	tokensText, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("unexpected token count %q: %w", tokensText, err)
+	}
+	return tokenBucketResult(limit, allowed == 1, tokens), nil
+}
+
+// slidingWindow runs slidingWindowScript on the counters of the current and previous windows
+func (s *RedisRateLimitStore) slidingWindow(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	start := windowStart(now, limit.Window)
+	index := start.UnixMilli() / limit.Window.Milliseconds()
+	elapsed := now.Sub(start)
+	keys := []string{
+		fmt.Sprintf("%s:%d", key, index),
+		fmt.Sprintf("%s:%d", key, index-1),
+	}
+
+	values, err := slidingWindowScript.Run(ctx, s.client, keys, limit.Requests, elapsed.Milliseconds(), limit.Window.Milliseconds()).Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(values) != 3 {
+		return RateLimitResult{}, fmt.Errorf("unexpected sliding window reply: %v", values)
+	}
+
+	allowed, _ := values[0].(int64)
+	previous, _ := values[1].(int64)
+	current, _ := values[2].(int64)
+	return slidingWindowResult(limit, allowed == 1, int(previous), int(current), elapsed), nil
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// newTestRedisStore starts an in-process Redis stand-in and returns a store using it
+func newTestRedisStore(t *testing.T) (*RedisRateLimitStore, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisRateLimitStore(client), server
+}
+
+func TestRedisRateLimitStoreTokenBucket(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	testTokenBucket(t, store)
+}
+
+func TestRedisRateLimitStoreSlidingWindow(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	testSlidingWindow(t, store)
+}
+
+func TestRedisRateLimitStoreKeys(t *testing.T) {
+	store, server := newTestRedisStore(t)
+
+	bucket := Limit{Algorithm: TokenBucket, Requests: 3, Window: 3 * time.Second}
+	allow(t, store, "bucket", bucket, at(0), true, 2)
+	if !server.Exists("ratelimit:{bucket}") {
+		t.Fatalf("missing token bucket key, have %v", server.Keys())
+	}
+	// The bucket expires once it would be full again, plus a second of margin
+	if ttl := server.TTL("ratelimit:{bucket}"); ttl != 2*time.Second {
+		t.Fatalf("token bucket TTL %v, want 2s", ttl)
+	}
+
+	window := Limit{Algorithm: SlidingWindow, Requests: 10, Window: time.Minute}
+	allow(t, store, "window", window, at(0), true, 9)
+	current := fmt.Sprintf("ratelimit:{window}:%d", start.UnixMilli()/time.Minute.Milliseconds())
+	if !server.Exists(current) {
+		t.Fatalf("missing sliding window key %s, have %v", current, server.Keys())
+	}
+	// The count of a window matters until the end of the next one
+	if ttl := server.TTL(current); ttl != 2*time.Minute {
+		t.Fatalf("sliding window TTL %v, want 2m", ttl)
+	}
+}
@@ -0,0 +1,215 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// start is a fake "now", aligned on a minute so the windows of the tests start on it
+var start = time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+
+// at returns the fake time d after start
+func at(d time.Duration) time.Time {
+	return start.Add(d)
+}
+
+// allow runs one check and fails the test if the store errors or doesn't answer as expected
+func allow(t *testing.T, store RateLimitStore, key string, limit Limit, now time.Time, wantAllowed bool, wantRemaining int) RateLimitResult {
+	t.Helper()
+	result, err := store.Allow(context.Background(), key, limit, now)
+	if err != nil {
+		t.Fatalf("Allow at %v: %v", now.Sub(start), err)
+	}
+	if result.Allowed != wantAllowed || result.Remaining != wantRemaining {
+		t.Fatalf("Allow at %v = allowed %v, remaining %d; want allowed %v, remaining %d",
+			now.Sub(start), result.Allowed, result.Remaining, wantAllowed, wantRemaining)
+	}
+	return result
+}
+
+// assertDuration fails the test if got isn't want, give or take a millisecond (the Redis store works in milliseconds)
+func assertDuration(t *testing.T, name string, got time.Duration, want time.Duration) {
+	t.Helper()
+	if diff := got - want; diff < -time.Millisecond || diff > time.Millisecond {
+		t.Fatalf("%s = %v, want %v", name, got, want)
+	}
+}
+
+// testTokenBucket checks the token bucket maths of a store: 3 requests of burst, refilled at one per second
+func testTokenBucket(t *testing.T, store RateLimitStore) {
+	limit := Limit{Algorithm: TokenBucket, Requests: 3, Window: 3 * time.Second}
+
+	// The burst empties the bucket
+	result := allow(t, store, "bucket", limit, at(0), true, 2)
+	assertDuration(t, "Reset", result.Reset, time.Second)
+	allow(t, store, "bucket", limit, at(0), true, 1)
+	result = allow(t, store, "bucket", limit, at(0), true, 0)
+	assertDuration(t, "Reset", result.Reset, 3*time.Second)
+
+	// One token comes back every second
+	result = allow(t, store, "bucket", limit, at(0), false, 0)
+	assertDuration(t, "RetryAfter", result.RetryAfter, time.Second)
+	result = allow(t, store, "bucket", limit, at(500*time.Millisecond), false, 0)
+	assertDuration(t, "RetryAfter", result.RetryAfter, 500*time.Millisecond)
+	allow(t, store, "bucket", limit, at(time.Second), true, 0)
+
+	// The bucket never holds more than its capacity
+	result = allow(t, store, "bucket", limit, at(time.Minute), true, 2)
+	assertDuration(t, "Reset", result.Reset, time.Second)
+
+	// Keys don't share their buckets
+	allow(t, store, "other", limit, at(time.Minute), true, 2)
+}
+
+// testSlidingWindow checks the sliding window maths of a store: 10 requests in any minute
+func testSlidingWindow(t *testing.T, store RateLimitStore) {
+	limit := Limit{Algorithm: SlidingWindow, Requests: 10, Window: time.Minute}
+
+	// The first window fills up
+	for i := 0; i < 10; i++ {
+		result := allow(t, store, "window", limit, at(time.Duration(i)*time.Second), true, 9-i)
+		assertDuration(t, "Reset", result.Reset, time.Minute-time.Duration(i)*time.Second)
+	}
+
+	// With no previous window, the next request fits once 10% of the next window went by,
+	// when the 10 requests only weigh 9
+	result := allow(t, store, "window", limit, at(10*time.Second), false, 0)
+	assertDuration(t, "RetryAfter", result.RetryAfter, 56*time.Second)
+	allow(t, store, "window", limit, at(66*time.Second), true, 0)
+
+	// 15s into the second window, the previous one weighs 7.5: one more request fits, not two
+	allow(t, store, "window", limit, at(75*time.Second), true, 0)
+	result = allow(t, store, "window", limit, at(75*time.Second), false, 0)
+	assertDuration(t, "RetryAfter", result.RetryAfter, 3*time.Second)
+	allow(t, store, "window", limit, at(79*time.Second), true, 0)
+
+	// After a whole window without requests, the counts start over
+	allow(t, store, "window", limit, at(200*time.Second), true, 9)
+}
+
+func TestMemoryRateLimitStoreTokenBucket(t *testing.T) {
+	testTokenBucket(t, NewMemoryRateLimitStore())
+}
+
+func TestMemoryRateLimitStoreSlidingWindow(t *testing.T) {
+	testSlidingWindow(t, NewMemoryRateLimitStore())
+}
+
+func TestMemoryRateLimitStoreSweep(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	limit := Limit{Algorithm: TokenBucket, Requests: 3, Window: 3 * time.Second}
+	allow(t, store, "bucket", limit, at(0), true, 2)
+
+	// Once the bucket is full again, the entry is dropped on the next sweep
+	allow(t, store, "other", limit, at(2*rateLimitSweepInterval), true, 2)
+	if _, ok := store.entries["bucket"]; ok {
+		t.Fatal("the refilled bucket wasn't swept")
+	}
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", RateLimit(RateLimitConfig{
+		Name:  "test",
+		Limit: Limit{Algorithm: TokenBucket, Requests: 2, Window: time.Minute},
+		Store: NewMemoryRateLimitStore(),
+	}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for i, want := range []struct {
+		status    int
+		remaining string
+	}{{fiber.StatusOK, "1"}, {fiber.StatusOK, "0"}, {fiber.StatusTooManyRequests, "0"}} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != want.status {
+			t.Fatalf("request %d: status %d, want %d", i+1, resp.StatusCode, want.status)
+		}
+		if got := resp.Header.Get("RateLimit-Remaining"); got != want.remaining {
+			t.Fatalf("request %d: RateLimit-Remaining %q, want %q", i+1, got, want.remaining)
+		}
+		if got := resp.Header.Get("RateLimit-Limit"); got != "2" {
+			t.Fatalf("request %d: RateLimit-Limit %q, want 2", i+1, got)
+		}
+		if got := resp.Header.Get("RateLimit-Policy"); got != "2;w=60" {
+			t.Fatalf("request %d: RateLimit-Policy %q, want 2;w=60", i+1, got)
+		}
+		if want.status == fiber.StatusTooManyRequests {
+			if got := resp.Header.Get("Retry-After"); got != "30" {
+				t.Fatalf("Retry-After %q, want 30", got)
+			}
+		}
+	}
+}
+
+// failingStore is a RateLimitStore whose backend is down
+type failingStore struct{}
+
+func (failingStore) Allow(ctx context.Context, key string, limit Limit, now time.Time) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("backend down")
+}
+
+func TestRateLimitLetsRequestsThroughWhenTheStoreFails(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", RateLimit(RateLimitConfig{
+		Name:  "test",
+		Limit: Limit{Algorithm: TokenBucket, Requests: 1, Window: time.Minute},
+		Store: failingStore{},
+	}), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	for i := 0; i < 3; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i+1, resp.StatusCode)
+		}
+	}
+}
+
+func TestKeyByIPBehindTrustedProxy(t *testing.T) {
+	key := func(config fiber.Config) string {
+		app := fiber.New(config)
+		app.Get("/", func(c *fiber.Ctx) error {
+			return c.SendString(KeyByIP(c))
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := make([]byte, 64)
+		n, _ := resp.Body.Read(body)
+		return string(body[:n])
+	}
+
+	// Test requests come from 0.0.0.0, which stands for the proxy
+	if got := key(fiber.Config{}); got != "ip:0.0.0.0" {
+		t.Fatalf("without a trusted proxy, key %q, want the address of the connection", got)
+	}
+	trusted := fiber.Config{
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          []string{"0.0.0.0"},
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableIPValidation:      true,
+	}
+	if got := key(trusted); got != "ip:203.0.113.7" {
+		t.Fatalf("behind a trusted proxy, key %q, want ip:203.0.113.7", got)
+	}
+	trusted.TrustedProxies = []string{"10.0.0.1"}
+	if got := key(trusted); got != "ip:0.0.0.0" {
+		t.Fatalf("behind an untrusted proxy, key %q, want the address of the connection", got)
+	}
+}
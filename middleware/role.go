@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"GO-X/models" // Import the models package for the roles and their permissions
+	"GO-X/utils"  // Import utility functions to read the role claim
+
+	"github.com/gofiber/fiber/v2"  // Import the Fiber web framework
+	"github.com/golang-jwt/jwt/v4" // Import the JWT library for the claims type
+)
+
+// RequireRole returns a middleware that only lets through users whose role ranks at least as high as role
+// e.g. RequireRole(models.RoleModerator) accepts moderators and admins. It must come after ProtectRoute.
+func RequireRole(role string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !models.RoleAtLeast(CurrentRole(c), role) {
+			return forbidden(c)
+		}
+		return c.Next()
+	}
+}
+
+// RequirePermission returns a middleware that only lets through users whose role grants the permission
+// It must come after ProtectRoute
+func RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !models.RoleHasPermission(CurrentRole(c), permission) {
+			return forbidden(c)
+		}
+		return c.Next()
+	}
+}
+
+// CurrentRole returns the role of the authenticated user, read from the claims stored by ProtectRoute
+// The role is embedded in the token when it is issued; tokens are revoked when the role of their user
+// changes, so it can't be out of date for long. Tokens without the claim belong to regular users.
+func CurrentRole(c *fiber.Ctx) string {
+	claims, _ := c.Locals("claims").(jwt.MapClaims)
+	role := utils.TokenRole(claims)
+	if role == "" {
+		return models.RoleUser
+	}
+	return role
+}
+
+// forbidden writes the response sent when the role of the user doesn't allow the request
+func forbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"status":  "error",
+		"message": "You don't have permission to do this",
+	})
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"strings" // For manipulating strings (e.g., trimming prefixes)
+
+	"github.com/gofiber/contrib/websocket" // Import the Fiber WebSocket middleware to detect upgrade requests
+	"github.com/gofiber/fiber/v2"          // Import the Fiber web framework
+)
+
+// WebSocketProtocol is the subprotocol a client names to pass its token in the handshake
+// Browsers can't set the Authorization header on a WebSocket, so they open it with
+// new WebSocket(url, ["bearer", token]); the server answers with this protocol only, never echoing the token.
+const WebSocketProtocol = "bearer"
+
+// ProtectWebSocket authenticates WebSocket upgrade requests with the same JWT checks as ProtectRoute
+// The token is read from the Authorization header or from Sec-WebSocket-Protocol. It is never taken from the
+// query string, where it would end up in the logs of proxies and of the server.
+func ProtectWebSocket(c *fiber.Ctx) error {
+	// Only WebSocket handshakes are accepted on this route
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{
+			"status":  "error",
+			"message": "WebSocket upgrade required",
+		})
+	}
+
+	// Read the token from the Authorization header, or from the protocols offered by the client
+	tokenString := protocolToken(c.Get("Sec-WebSocket-Protocol"))
+	if authHeader := c.Get("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+		tokenString = strings.TrimPrefix(authHeader, "Bearer ")
+	}
+	if tokenString == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Missing authorization token",
+		})
+	}
+
+	// Validate the token and make sure it hasn't been revoked
+	claims, err := checkToken(c, tokenString)
+	if claims == nil {
+		return err
+	}
+
+	// The claims are copied to the WebSocket connection, where conn.Locals("claims") reads them
+	c.Locals("claims", claims)
+	return c.Next()
+}
+
+// protocolToken reads the token from a "bearer, <token>" Sec-WebSocket-Protocol header
+// It returns "" when the header doesn't have that form.
+func protocolToken(header string) string {
+	protocols := strings.Split(header, ",")
+	if len(protocols) != 2 || strings.TrimSpace(protocols[0]) != WebSocketProtocol {
+		return ""
+	}
+	return strings.TrimSpace(protocols[1])
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+)
+
+// BlockUser records that blockerID blocks blockedID and removes the follows between them, both ways
+// It returns false (and no error) when the block already existed
+func BlockUser(db *sql.DB, blockerID int, blockedID int) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	if _, err := tx.Exec("INSERT INTO blocks (blocker_id, blocked_id) VALUES (?, ?)", blockerID, blockedID); err != nil {
+		if isDuplicateKeyError(err) {
+			return false, nil // Already blocked
+		}
+		return false, err
+	}
+	_, err = tx.Exec("DELETE FROM followers WHERE (follower_id = ? AND following_id = ?) OR (follower_id = ? AND following_id = ?)",
+		blockerID, blockedID, blockedID, blockerID)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// UnblockUser removes the block of blockerID on blockedID; the follows it removed are not restored
+// It returns false (and no error) when there was no such block
+func UnblockUser(db *sql.DB, blockerID int, blockedID int) (bool, error) {
+	result, err := db.Exec("DELETE FROM blocks WHERE blocker_id = ? AND blocked_id = ?", blockerID, blockedID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// IsBlocking reports whether blockerID blocks blockedID
+func IsBlocking(db *sql.DB, blockerID int, blockedID int) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM blocks WHERE blocker_id = ? AND blocked_id = ?)", blockerID, blockedID).Scan(&exists)
+	return exists, err
+}
+
+// IsBlockedEitherWay reports whether one of the two users blocks the other
+func IsBlockedEitherWay(db *sql.DB, userID int, otherID int) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM blocks WHERE (blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?))",
+		userID, otherID, otherID, userID).Scan(&exists)
+	return exists, err
+}
+
+// GetBlockedIDs returns the IDs of every user blockerID blocks
+func GetBlockedIDs(db *sql.DB, blockerID int) (map[int]bool, error) {
+	rows, err := db.Query("SELECT blocked_id FROM blocks WHERE blocker_id = ?", blockerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blocked := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		blocked[id] = true
+	}
+	return blocked, rows.Err()
+}
+
+// GetBlockersAmong returns which of the users in userIDs block blockedID
+func GetBlockersAmong(db *sql.DB, blockedID int, userIDs []int) (map[int]bool, error) {
+	args := append([]interface{}{blockedID}, intArgs(userIDs)...)
+	rows, err := db.Query("SELECT blocker_id FROM blocks WHERE blocked_id = ? AND blocker_id IN ("+placeholders(len(userIDs))+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blockers := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		blockers[id] = true
+	}
+	return blockers, rows.Err()
+}
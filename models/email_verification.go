@@ -0,0 +1,67 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"time"         // To work with the expiration time of the verification token
+)
+
+// EmailVerification represents a row in the "email_verifications" table
+// The Token field holds the SHA-256 hash of the token, never the token that was emailed to the user
+type EmailVerification struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Token     string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// CreateEmailVerification stores a new hashed verification token for a user
+// Any older token of the same user is removed first, so only the latest email can be used
+func CreateEmailVerification(db *sql.DB, userID int, tokenHash string, expiredAt time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	if _, err := tx.Exec("DELETE FROM email_verifications WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO email_verifications (user_id, token, expired_at) VALUES (?, ?, ?)", userID, tokenHash, expiredAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumeEmailVerification looks up a verification token by its hash and deletes it in the same transaction
+// This makes the token single-use. It returns nil (and no error) when the token doesn't exist or was already used.
+func ConsumeEmailVerification(db *sql.DB, tokenHash string) (*EmailVerification, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var verification EmailVerification
+	err = tx.QueryRow("SELECT id, user_id, token, created_at, expired_at FROM email_verifications WHERE token = ? FOR UPDATE", tokenHash).
+		Scan(&verification.ID, &verification.UserID, &verification.Token, &verification.CreatedAt, &verification.ExpiredAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Unknown or already used token
+		}
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM email_verifications WHERE id = ?", verification.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// Expired reports whether the verification token is past its expiration time
+func (v *EmailVerification) Expired() bool {
+	return time.Now().After(v.ExpiredAt)
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"time"         // To work with the time of each like or retweet
+)
+
+// Engagement represents one user who liked or retweeted a tweet
+// ID is the row ID in the "likes" or "retweets" table and is only used for pagination
+type Engagement struct {
+	ID        int       `json:"-"`
+	UserID    int       `json:"user_id"`
+	Username  string    `json:"username"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// engagementTables lists the tables storing engagements, so the table name is never built from user input
+var engagementTables = map[string]string{
+	"likes":    "likes",
+	"retweets": "retweets",
+}
+
+// addEngagement inserts a (user_id, tweet_id) row in the given table
+// It returns false (and no error) when the row already exists, which makes the operation idempotent
+func addEngagement(db *sql.DB, table string, userID int, tweetID int) (bool, error) {
+	_, err := db.Exec("INSERT INTO "+engagementTables[table]+" (user_id, tweet_id) VALUES (?, ?)", userID, tweetID)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return false, nil // Already there, nothing to do
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// removeEngagement deletes the (user_id, tweet_id) row from the given table
+// It returns false (and no error) when there was nothing to delete
+func removeEngagement(db *sql.DB, table string, userID int, tweetID int) (bool, error) {
+	result, err := db.Exec("DELETE FROM "+engagementTables[table]+" WHERE user_id = ? AND tweet_id = ?", userID, tweetID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// listEngagements returns the users who engaged with a tweet, newest first
+// Only rows with an ID lower than beforeID are returned (0 means from the start)
+func listEngagements(db *sql.DB, table string, tweetID int, beforeID int, limit int) ([]Engagement, error) {
+	query := `SELECT e.id, e.user_id, u.username, e.created_at
+		FROM ` + engagementTables[table] + ` e
+		JOIN users u ON u.id = e.user_id AND u.deleted_at IS NULL
+		WHERE e.tweet_id = ? AND (? = 0 OR e.id < ?)
+		ORDER BY e.id DESC
+		LIMIT ?`
+	rows, err := db.Query(query, tweetID, beforeID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	engagements := []Engagement{}
+	for rows.Next() {
+		var engagement Engagement
+		if err := rows.Scan(&engagement.ID, &engagement.UserID, &engagement.Username, &engagement.CreatedAt); err != nil {
+			return nil, err
+		}
+		engagements = append(engagements, engagement)
+	}
+	return engagements, rows.Err()
+}
+
+// LikeTweet records that the user likes the tweet
+// It returns false when the user had already liked it
+func LikeTweet(db *sql.DB, userID int, tweetID int) (bool, error) {
+	return addEngagement(db, "likes", userID, tweetID)
+}
+
+// UnlikeTweet removes the like of the user on the tweet
+// It returns false when the user hadn't liked it
+func UnlikeTweet(db *sql.DB, userID int, tweetID int) (bool, error) {
+	return removeEngagement(db, "likes", userID, tweetID)
+}
+
+// GetTweetLikes lists the users who liked a tweet, newest first
+func GetTweetLikes(db *sql.DB, tweetID int, beforeID int, limit int) ([]Engagement, error) {
+	return listEngagements(db, "likes", tweetID, beforeID, limit)
+}
+
+// RetweetTweet records that the user retweets the tweet
+// It returns false when the user had already retweeted it
+func RetweetTweet(db *sql.DB, userID int, tweetID int) (bool, error) {
+	return addEngagement(db, "retweets", userID, tweetID)
+}
+
+// UnretweetTweet removes the retweet of the user
+// It returns false when the user hadn't retweeted it
+func UnretweetTweet(db *sql.DB, userID int, tweetID int) (bool, error) {
+	return removeEngagement(db, "retweets", userID, tweetID)
+}
+
+// GetTweetRetweets lists the users who retweeted a tweet, newest first
+func GetTweetRetweets(db *sql.DB, tweetID int, beforeID int, limit int) ([]Engagement, error) {
+	return listEngagements(db, "retweets", tweetID, beforeID, limit)
+}
+
+// GetRetweet retrieves the retweet of a tweet by a user
+// It returns nil (and no error) when the user hasn't retweeted the tweet
+func GetRetweet(db *sql.DB, userID int, tweetID int) (*Engagement, error) {
+	var retweet Engagement
+	err := db.QueryRow(`SELECT r.id, r.user_id, u.username, r.created_at
+		FROM retweets r JOIN users u ON u.id = r.user_id
+		WHERE r.user_id = ? AND r.tweet_id = ?`, userID, tweetID).
+		Scan(&retweet.ID, &retweet.UserID, &retweet.Username, &retweet.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Not retweeted
+		}
+		return nil, err
+	}
+	return &retweet, nil
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"GO-X/entities" // Import the entities package to find the hashtags of a tweet
+	"database/sql"  // Import the database/sql package to interact with SQL databases
+)
+
+// TweetEntities lists what the content of a tweet refers to, with where it is in the text
+// Offsets are in runes (Unicode code points) of the content.
+type TweetEntities struct {
+	Hashtags []entities.Hashtag `json:"hashtags"`
+	Mentions []Mention          `json:"mentions"` // Only the mentions of existing users
+}
+
+// setTweetEntities replaces the hashtags and mentions linked to a tweet with those found in its content
+// It runs inside the transaction that saves the content, so the indexes never disagree with the text.
+func setTweetEntities(tx *sql.Tx, tweetID int, content string) error {
+	if err := setTweetHashtags(tx, tweetID, content); err != nil {
+		return err
+	}
+	return setTweetMentions(tx, tweetID, content)
+}
+
+// IndexEntitiesAfter recomputes the hashtags and mentions of up to limit tweets with an ID greater than afterID, in ID order
+// It returns the ID of the last tweet indexed, or 0 once there are none left. It is used to index
+// the tweets posted before hashtags and mentions were extracted (see cmd/index-entities).
+func IndexEntitiesAfter(db *sql.DB, afterID int, limit int) (int, error) {
+	rows, err := db.Query("SELECT id, content FROM tweets WHERE id > ? ORDER BY id LIMIT ?", afterID, limit)
+	if err != nil {
+		return 0, err
+	}
+	type tweetContent struct {
+		id      int
+		content string
+	}
+	batch := []tweetContent{}
+	for rows.Next() {
+		var tweet tweetContent
+		if err := rows.Scan(&tweet.id, &tweet.content); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		batch = append(batch, tweet)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	lastID := 0
+	for _, tweet := range batch {
+		tx, err := db.Begin()
+		if err != nil {
+			return 0, err
+		}
+		if err := setTweetEntities(tx, tweet.id, tweet.content); err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+		lastID = tweet.id
+	}
+	return lastID, nil
+}
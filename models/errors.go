@@ -0,0 +1,25 @@
+package models
+
+import (
+	"errors" // To unwrap driver errors
+
+	"github.com/go-sql-driver/mysql" // Import the MySQL driver to read its error codes
+)
+
+// MySQL error numbers
+const (
+	mysqlDuplicateEntry = 1062 // "Duplicate entry ... for key ..."
+	mysqlDeadlock       = 1213 // "Deadlock found when trying to get lock; try restarting transaction"
+)
+
+// isDuplicateKeyError reports whether err was caused by a UNIQUE constraint
+func isDuplicateKeyError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry
+}
+
+// isDeadlockError reports whether err means the transaction was rolled back as a deadlock victim
+func isDeadlockError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	return errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDeadlock
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"time"         // To work with the time a follow happened
+)
+
+// UserProfile is the public view of a user, with follower and following counts
+// Unlike User, it never carries the email or the password
+type UserProfile struct {
+	ID          int    `json:"id"`
+	Username    string `json:"username"`
+	DisplayName string `json:"display_name"` // Name shown instead of the username, may be empty
+	Bio         string `json:"bio"`          // Short description written by the user
+	Location    string `json:"location"`     // Free-form location, e.g. "Paris"
+	Website     string `json:"website"`      // http(s) URL of the user's website
+	AvatarURL   string `json:"avatar_url"`   // http(s) URL of the profile picture
+	BannerURL   string `json:"banner_url"`   // http(s) URL of the header image
+	// Details and follow lists are only shown to followers (see HideDetails); tweets stay public either way
+	HideProfileDetails bool      `json:"hide_profile_details"`
+	FollowersCount     int       `json:"followers_count"` // Number of users following this user
+	FollowingCount     int       `json:"following_count"` // Number of users this user follows
+	CreatedAt          time.Time `json:"created_at"`      // When the account was created
+}
+
+// FollowEntry is one user in a followers or following list
+// ID is the row ID in the "followers" table and is only used for pagination
+type FollowEntry struct {
+	ID int `json:"-"`
+	UserProfile
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// profileColumns selects the UserProfile fields of the user aliased as "u"
+const profileColumns = `u.id, u.username,
+		u.display_name, u.bio, u.location, u.website, u.avatar_url, u.banner_url, u.hide_profile_details,
+		(SELECT COUNT(*) FROM followers fc WHERE fc.following_id = u.id) AS followers_count,
+		(SELECT COUNT(*) FROM followers fc WHERE fc.follower_id = u.id) AS following_count,
+		u.created_at`
+
+// profileDest returns the scan destinations matching profileColumns
+func profileDest(profile *UserProfile) []interface{} {
+	return []interface{}{
+		&profile.ID, &profile.Username,
+		&profile.DisplayName, &profile.Bio, &profile.Location, &profile.Website, &profile.AvatarURL, &profile.BannerURL, &profile.HideProfileDetails,
+		&profile.FollowersCount, &profile.FollowingCount, &profile.CreatedAt,
+	}
+}
+
+// GetUserProfile retrieves the public profile of a user
+// It returns nil (and no error) when the user doesn't exist
+func GetUserProfile(db *sql.DB, userID int) (*UserProfile, error) {
+	return getUserProfile(db, "u.id = ?", userID)
+}
+
+// GetUserProfileByUsername retrieves the public profile of a user by their username
+// It returns nil (and no error) when the user doesn't exist
+func GetUserProfileByUsername(db *sql.DB, username string) (*UserProfile, error) {
+	return getUserProfile(db, "u.username = ?", username)
+}
+
+// getUserProfile runs the query shared by GetUserProfile and GetUserProfileByUsername
+func getUserProfile(db *sql.DB, filter string, value interface{}) (*UserProfile, error) {
+	var profile UserProfile
+	err := db.QueryRow("SELECT "+profileColumns+" FROM users u WHERE "+filter+" AND u.deleted_at IS NULL", value).
+		Scan(profileDest(&profile)...)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No user found
+		}
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// FollowUser records that followerID follows followingID
+// It returns false (and no error) when the follow already existed
+func FollowUser(db *sql.DB, followerID int, followingID int) (bool, error) {
+	_, err := db.Exec("INSERT INTO followers (follower_id, following_id) VALUES (?, ?)", followerID, followingID)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return false, nil // Already following
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// UnfollowUser removes the follow of followerID on followingID
+// It returns false (and no error) when there was no such follow
+func UnfollowUser(db *sql.DB, followerID int, followingID int) (bool, error) {
+	result, err := db.Exec("DELETE FROM followers WHERE follower_id = ? AND following_id = ?", followerID, followingID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// IsFollowing reports whether followerID follows followingID
+func IsFollowing(db *sql.DB, followerID int, followingID int) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM followers WHERE follower_id = ? AND following_id = ?)", followerID, followingID).Scan(&exists)
+	return exists, err
+}
+
+// GetFollowers lists the users following userID, most recent follow first
+// Only rows with an ID lower than beforeID are returned (0 means from the start)
+func GetFollowers(db *sql.DB, userID int, beforeID int, limit int) ([]FollowEntry, error) {
+	return listFollows(db, "f.following_id = ?", "f.follower_id", userID, beforeID, limit)
+}
+
+// GetFollowing lists the users userID follows, most recent follow first
+// Only rows with an ID lower than beforeID are returned (0 means from the start)
+func GetFollowing(db *sql.DB, userID int, beforeID int, limit int) ([]FollowEntry, error) {
+	return listFollows(db, "f.follower_id = ?", "f.following_id", userID, beforeID, limit)
+}
+
+// listFollows runs the query shared by GetFollowers and GetFollowing
+// filter selects the rows of the user, joinColumn is the column pointing at the users to list
+func listFollows(db *sql.DB, filter string, joinColumn string, userID int, beforeID int, limit int) ([]FollowEntry, error) {
+	query := `SELECT f.id, ` + profileColumns + `, f.created_at
+		FROM followers f
+		JOIN users u ON u.id = ` + joinColumn + ` AND u.deleted_at IS NULL
+		WHERE ` + filter + ` AND (? = 0 OR f.id < ?)
+		ORDER BY f.id DESC
+		LIMIT ?`
+	rows, err := db.Query(query, userID, beforeID, beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []FollowEntry{}
+	for rows.Next() {
+		var entry FollowEntry
+		dest := append([]interface{}{&entry.ID}, profileDest(&entry.UserProfile)...)
+		if err := rows.Scan(append(dest, &entry.FollowedAt)...); err != nil {
+			return nil, err
+		}
+		// Lists don't check who follows whom, so accounts hiding their details only show their public card
+		if entry.HideProfileDetails {
+			entry.HideDetails()
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// GetFollowerIDs returns the IDs of every user following userID
+// It is used to fan a new tweet out to the timelines of the followers
+func GetFollowerIDs(db *sql.DB, userID int) ([]int, error) {
+	rows, err := db.Query(`SELECT f.follower_id FROM followers f
+		JOIN users u ON u.id = f.follower_id AND u.deleted_at IS NULL
+		WHERE f.following_id = ?`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// CountFollowers returns the number of users following userID
+func CountFollowers(db *sql.DB, userID int) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM followers WHERE following_id = ?", userID).Scan(&count)
+	return count, err
+}
+
+// GetFollowedAccountsAbove returns the accounts followed by userID that have more than threshold followers
+func GetFollowedAccountsAbove(db *sql.DB, userID int, threshold int) ([]int, error) {
+	rows, err := db.Query(`SELECT f.following_id FROM followers f
+		WHERE f.follower_id = ?
+		AND (SELECT COUNT(*) FROM followers c WHERE c.following_id = f.following_id) > ?`, userID, threshold)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"GO-X/entities" // Import the entities package to find the hashtags of a tweet
+	"database/sql"  // Import the database/sql package to interact with SQL databases
+)
+
+// setTweetHashtags replaces the hashtags linked to a tweet with those found in its content
+func setTweetHashtags(tx *sql.Tx, tweetID int, content string) error {
+	if _, err := tx.Exec("DELETE FROM tweet_hashtags WHERE tweet_id = ?", tweetID); err != nil {
+		return err
+	}
+
+	for _, tag := range entities.HashtagTags(entities.ExtractHashtags(content)) {
+		// Create the tag the first time it is used; LAST_INSERT_ID(id) makes LastInsertId return
+		// the ID of the existing row otherwise
+		result, err := tx.Exec("INSERT INTO hashtags (tag) VALUES (?) ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)", tag)
+		if err != nil {
+			return err
+		}
+		hashtagID, err := result.LastInsertId()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO tweet_hashtags (tweet_id, hashtag_id) VALUES (?, ?)", tweetID, hashtagID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetHashtagTweets lists the tweets carrying a hashtag, most recent first
+// tag must be normalized (see entities.NormalizeHashtag). Only tweets with an ID lower than beforeID
+// are returned (0 means from the start).
+func GetHashtagTweets(db *sql.DB, tag string, beforeID int, limit int) ([]*Tweet, error) {
+	return queryTweets(db, tweetSelect+`
+		JOIN tweet_hashtags th ON th.tweet_id = t.id
+		JOIN hashtags h ON h.id = th.hashtag_id
+		WHERE h.tag = ? AND (? = 0 OR t.id < ?)
+		ORDER BY t.id DESC LIMIT ?`, tag, beforeID, beforeID, limit)
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"sort"         // To lock the records of an attempt in a fixed order
+	"time"         // To work with failure and lockout times
+)
+
+// Scopes of login throttles
+const (
+	LoginScopeUser = "user" // Failures against one account, whatever the client
+	LoginScopeIP   = "ip"   // Failures from one client IP, whatever the account
+)
+
+// LockoutPolicy decides how long logins are blocked after failed attempts
+// The first FreeAttempts failures cost nothing. Each following failure blocks the next attempt for
+// BaseDelay, doubled every time, up to MaxDelay. After LockoutThreshold failures, logins are blocked
+// for LockoutDuration. Failures are forgotten after ResetAfter without a new failure.
+type LockoutPolicy struct {
+	FreeAttempts     int
+	BaseDelay        time.Duration
+	MaxDelay         time.Duration
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	ResetAfter       time.Duration
+}
+
+// Delay returns how long logins are blocked after the given number of consecutive failures
+func (p LockoutPolicy) Delay(failures int) time.Duration {
+	if failures >= p.LockoutThreshold {
+		return p.LockoutDuration
+	}
+	if failures <= p.FreeAttempts {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// LoginThrottle represents a row in the "login_throttles" table
+type LoginThrottle struct {
+	Scope         string     `json:"scope"`
+	Subject       string     `json:"subject"` // Username (lower case) or IP address
+	Failures      int        `json:"failures"`
+	LastFailureAt *time.Time `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until"`
+}
+
+// RetryAfter returns how long logins are still blocked, or 0 when they aren't
+func (t *LoginThrottle) RetryAfter(now time.Time) time.Duration {
+	if t == nil || t.LockedUntil == nil || !t.LockedUntil.After(now) {
+		return 0
+	}
+	return t.LockedUntil.Sub(now)
+}
+
+// GetLoginThrottle retrieves the failed login record of an account or an IP
+// It returns nil (and no error) when there was no recent failure
+func GetLoginThrottle(db *sql.DB, scope string, subject string) (*LoginThrottle, error) {
+	throttle := LoginThrottle{Scope: scope, Subject: subject}
+	var lastFailureAt, lockedUntil sql.NullTime
+	err := db.QueryRow("SELECT failures, last_failure_at, locked_until FROM login_throttles WHERE scope = ? AND subject = ?", scope, subject).
+		Scan(&throttle.Failures, &lastFailureAt, &lockedUntil)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No failure recorded
+		}
+		return nil, err
+	}
+	if lastFailureAt.Valid {
+		throttle.LastFailureAt = &lastFailureAt.Time
+	}
+	if lockedUntil.Valid {
+		throttle.LockedUntil = &lockedUntil.Time
+	}
+	return &throttle, nil
+}
+
+// ReserveLoginAttempt counts a login attempt against several subjects (scope → subject) before the credentials are checked
+// The rows are locked while they are read and updated, so concurrent attempts can't all pass the check before any of
+// them is counted. Either no subject is blocked and the attempt is counted as a failure of every subject, or nothing
+// is counted and the time to wait is returned. The counters start over when the previous failure is older than
+// the policy's ResetAfter. An attempt that succeeds is taken back with ReleaseLoginAttempt.
+// It returns the updated records (nil when blocked) and how long the attempt must wait (0 when it may go on).
+func ReserveLoginAttempt(db *sql.DB, subjects map[string]string, policies map[string]LockoutPolicy, now time.Time) ([]*LoginThrottle, time.Duration, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, 0, err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	// Lock the rows in a fixed order, so two attempts never wait on each other
+	scopes := make([]string, 0, len(subjects))
+	for scope := range subjects {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+
+	throttles := make([]*LoginThrottle, 0, len(scopes))
+	var retryAfter time.Duration
+	for _, scope := range scopes {
+		throttle, err := lockLoginThrottle(tx, scope, subjects[scope])
+		if err != nil {
+			return nil, 0, err
+		}
+		if wait := throttle.RetryAfter(now); wait > retryAfter {
+			retryAfter = wait
+		}
+		throttles = append(throttles, throttle)
+	}
+	if retryAfter > 0 {
+		return nil, retryAfter, nil // Blocked: the rollback leaves the counters as they were
+	}
+
+	for _, throttle := range throttles {
+		policy := policies[throttle.Scope]
+		if throttle.LastFailureAt == nil || now.Sub(*throttle.LastFailureAt) > policy.ResetAfter {
+			throttle.Failures = 0
+		}
+		throttle.Failures++
+		throttle.LastFailureAt = &now
+		throttle.LockedUntil = nil
+		if delay := policy.Delay(throttle.Failures); delay > 0 {
+			until := now.Add(delay)
+			throttle.LockedUntil = &until
+		}
+		if err := updateLoginThrottle(tx, throttle); err != nil {
+			return nil, 0, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, 0, err
+	}
+	return throttles, 0, nil
+}
+
+// ReleaseLoginAttempt takes back an attempt counted by ReserveLoginAttempt once it succeeded
+// The counter goes down by one; a delay is only lifted when the remaining failures don't call for one,
+// so a delay set by a concurrent failed attempt stays.
+func ReleaseLoginAttempt(db *sql.DB, scope string, subject string, policy LockoutPolicy) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	throttle, err := lockLoginThrottle(tx, scope, subject)
+	if err != nil {
+		return err
+	}
+	if throttle.Failures > 0 {
+		throttle.Failures--
+	}
+	if policy.Delay(throttle.Failures) == 0 {
+		throttle.LockedUntil = nil
+	}
+	if err := updateLoginThrottle(tx, throttle); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// lockLoginThrottle creates the record of a subject if needed, then reads it and locks it until the end of tx
+func lockLoginThrottle(tx *sql.Tx, scope string, subject string) (*LoginThrottle, error) {
+	if _, err := tx.Exec("INSERT IGNORE INTO login_throttles (scope, subject) VALUES (?, ?)", scope, subject); err != nil {
+		return nil, err
+	}
+	throttle := &LoginThrottle{Scope: scope, Subject: subject}
+	var lastFailureAt, lockedUntil sql.NullTime
+	err := tx.QueryRow("SELECT failures, last_failure_at, locked_until FROM login_throttles WHERE scope = ? AND subject = ? FOR UPDATE", scope, subject).
+		Scan(&throttle.Failures, &lastFailureAt, &lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+	if lastFailureAt.Valid {
+		throttle.LastFailureAt = &lastFailureAt.Time
+	}
+	if lockedUntil.Valid {
+		throttle.LockedUntil = &lockedUntil.Time
+	}
+	return throttle, nil
+}
+
+// updateLoginThrottle writes the counters of a record locked by lockLoginThrottle
+func updateLoginThrottle(tx *sql.Tx, throttle *LoginThrottle) error {
+	var lockedUntil interface{} // NULL when the next attempt isn't delayed
+	if throttle.LockedUntil != nil {
+		lockedUntil = *throttle.LockedUntil
+	}
+	_, err := tx.Exec("UPDATE login_throttles SET failures = ?, last_failure_at = ?, locked_until = ? WHERE scope = ? AND subject = ?",
+		throttle.Failures, throttle.LastFailureAt, lockedUntil, throttle.Scope, throttle.Subject)
+	return err
+}
+
+// ClearLoginFailures forgets the failed logins of an account or an IP and lifts any lockout
+// It is called after a successful login, and by administrators to unlock an account.
+// It returns false when there was nothing to clear.
+func ClearLoginFailures(db *sql.DB, scope string, subject string) (bool, error) {
+	result, err := db.Exec("DELETE FROM login_throttles WHERE scope = ? AND subject = ?", scope, subject)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// PurgeLoginThrottles removes the records whose last failure happened before the given time
+// Their counters would start over anyway, so they only take space
+func PurgeLoginThrottles(db *sql.DB, before time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM login_throttles WHERE last_failure_at < ? AND (locked_until IS NULL OR locked_until < ?)", before, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
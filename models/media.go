@@ -0,0 +1,171 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"strconv"      // To build the URL of a file from its ID
+	"strings"      // To build the placeholders of IN (...) clauses
+	"time"         // To work with the upload time of media
+)
+
+// Media represents a row in the "media" table: an uploaded file and what we know about it
+// The file itself lives in a storage.Store under StorageKey. Until it is attached to a tweet
+// (TweetID is nil), only its uploader can use it, and it is garbage-collected after a while.
+type Media struct {
+	ID          int       `json:"id"`
+	UserID      int       `json:"user_id"`      // The uploader
+	TweetID     *int      `json:"-"`            // The tweet the media is attached to, nil while it isn't
+	StorageKey  string    `json:"-"`            // Where the file is stored, e.g. "media/Zx3...Q.png"
+	ContentType string    `json:"content_type"` // Sniffed from the content, never taken from the client
+	Size        int64     `json:"size"`         // In bytes
+	Width       int       `json:"width"`        // In pixels
+	Height      int       `json:"height"`       // In pixels
+	URL         string    `json:"url"`          // Where the file is served, relative to the API address
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// mediaColumns selects the fields of Media from the "media" table
+const mediaColumns = "id, user_id, tweet_id, storage_key, content_type, size_bytes, width, height, created_at"
+
+// scanMedia reads one row selected with mediaColumns
+func scanMedia(row rowScanner) (*Media, error) {
+	var media Media
+	var tweetID sql.NullInt64
+	err := row.Scan(&media.ID, &media.UserID, &tweetID, &media.StorageKey, &media.ContentType, &media.Size, &media.Width, &media.Height, &media.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if tweetID.Valid {
+		id := int(tweetID.Int64)
+		media.TweetID = &id
+	}
+	media.URL = "/media/" + strconv.Itoa(media.ID) + "/file"
+	return &media, nil
+}
+
+// CreateMedia saves a new, unattached media
+// On success the ID, URL and upload time of the media are filled in
+func CreateMedia(db *sql.DB, media *Media) error {
+	result, err := db.Exec("INSERT INTO media (user_id, storage_key, content_type, size_bytes, width, height) VALUES (?, ?, ?, ?, ?, ?)",
+		media.UserID, media.StorageKey, media.ContentType, media.Size, media.Width, media.Height)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	// Read the row back to get the upload time set by the database
+	created, err := GetMediaByID(db, int(id))
+	if err != nil {
+		return err
+	}
+	if created != nil {
+		*media = *created
+	}
+	return nil
+}
+
+// GetMediaByID retrieves a media by its ID
+// It returns nil (and no error) when the media doesn't exist
+func GetMediaByID(db *sql.DB, id int) (*Media, error) {
+	media, err := scanMedia(db.QueryRow("SELECT "+mediaColumns+" FROM media WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No media found
+		}
+		return nil, err
+	}
+	return media, nil
+}
+
+// AttachMedia attaches media uploaded by userID to a tweet, in the given order
+// Either every media is attached or none is: it returns false (and no error) when one of them
+// doesn't exist, belongs to someone else, is already attached to a tweet or was uploaded before
+// uploadedAfter (it is then due to be purged as an orphan).
+func AttachMedia(db *sql.DB, userID int, tweetID int, mediaIDs []int, uploadedAfter time.Time) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	for position, mediaID := range mediaIDs {
+		result, err := tx.Exec("UPDATE media SET tweet_id = ?, position = ? WHERE id = ? AND user_id = ? AND tweet_id IS NULL AND created_at >= ?",
+			tweetID, position, mediaID, userID, uploadedAfter)
+		if err != nil {
+			return false, err
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+			return false, err
+		}
+	}
+	return true, tx.Commit()
+}
+
+// attachTweetMedia loads the media of several tweets at once and stores them on the tweets
+func attachTweetMedia(db *sql.DB, tweets ...*Tweet) error {
+	if len(tweets) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*Tweet, len(tweets))
+	args := make([]interface{}, len(tweets))
+	for i, tweet := range tweets {
+		tweet.Media = []Media{}
+		byID[tweet.ID] = tweet
+		args[i] = tweet.ID
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(tweets)), ", ")
+	rows, err := db.Query("SELECT "+mediaColumns+" FROM media WHERE tweet_id IN ("+placeholders+") ORDER BY tweet_id, position", args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		media, err := scanMedia(rows)
+		if err != nil {
+			return err
+		}
+		if tweet := byID[*media.TweetID]; tweet != nil {
+			tweet.Media = append(tweet.Media, *media)
+		}
+	}
+	return rows.Err()
+}
+
+// GetOrphanedMedia returns up to limit media that aren't attached to any tweet and were uploaded before the given time
+// They were never posted, or their tweet was deleted (the foreign key sets tweet_id back to NULL)
+func GetOrphanedMedia(db *sql.DB, before time.Time, limit int) ([]Media, error) {
+	rows, err := db.Query("SELECT "+mediaColumns+" FROM media WHERE tweet_id IS NULL AND created_at < ? ORDER BY id LIMIT ?", before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orphans := []Media{}
+	for rows.Next() {
+		media, err := scanMedia(rows)
+		if err != nil {
+			return nil, err
+		}
+		orphans = append(orphans, *media)
+	}
+	return orphans, rows.Err()
+}
+
+// DeleteOrphanedMedia removes a media row if it still isn't attached to a tweet; the stored file must be deleted separately
+// It returns false (and no error) when the media was attached in the meantime, in which case its file must be kept.
+func DeleteOrphanedMedia(db *sql.DB, id int) (bool, error) {
+	result, err := db.Exec("DELETE FROM media WHERE id = ? AND tweet_id IS NULL", id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"GO-X/entities" // Import the entities package to find the mentions of a tweet
+	"database/sql"  // Import the database/sql package to interact with SQL databases
+	"strings"       // To match usernames regardless of case
+)
+
+// Mention is a user mentioned in a tweet, with where the "@username" is in its content
+type Mention struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"` // As registered, which may differ in case from the text
+	Start    int    `json:"start"`    // Offset of the "@", in runes
+	End      int    `json:"end"`      // Offset just after the username, in runes
+}
+
+// setTweetMentions replaces the mentions stored for a tweet with those found in its content
+// Only "@username" tokens naming an existing user are kept; a user mentioned twice is stored twice, once per offset.
+func setTweetMentions(tx *sql.Tx, tweetID int, content string) error {
+	if _, err := tx.Exec("DELETE FROM tweet_mentions WHERE tweet_id = ?", tweetID); err != nil {
+		return err
+	}
+
+	found := entities.ExtractMentions(content)
+	if len(found) == 0 {
+		return nil
+	}
+	names := make([]interface{}, len(found))
+	for i, mention := range found {
+		names[i] = mention.Username
+	}
+
+	// Resolve every name with one query; the comparison in Go keeps only exact (case-insensitive) matches,
+	// as the column collation would also match names that only differ by accents
+	rows, err := tx.Query("SELECT id, username FROM users WHERE username IN ("+placeholders(len(names))+") AND deleted_at IS NULL", names...)
+	if err != nil {
+		return err
+	}
+	userIDs := map[string]int{}
+	for rows.Next() {
+		var id int
+		var username string
+		if err := rows.Scan(&id, &username); err != nil {
+			rows.Close()
+			return err
+		}
+		userIDs[strings.ToLower(username)] = id
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, mention := range found {
+		userID, ok := userIDs[strings.ToLower(mention.Username)]
+		if !ok {
+			continue // Nobody goes by that name
+		}
+		if _, err := tx.Exec("INSERT INTO tweet_mentions (tweet_id, user_id, start_offset, end_offset) VALUES (?, ?, ?, ?)",
+			tweetID, userID, mention.Start, mention.End); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// attachTweetMentions loads the mentions of several tweets at once and stores them in the entities of the tweets
+// Mentions of users who deleted their account are left out.
+func attachTweetMentions(db *sql.DB, tweets ...*Tweet) error {
+	if len(tweets) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*Tweet, len(tweets))
+	ids := make([]int, len(tweets))
+	for i, tweet := range tweets {
+		tweet.Entities.Mentions = []Mention{}
+		byID[tweet.ID] = tweet
+		ids[i] = tweet.ID
+	}
+
+	rows, err := db.Query(`SELECT m.tweet_id, m.user_id, u.username, m.start_offset, m.end_offset
+		FROM tweet_mentions m
+		JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+		WHERE m.tweet_id IN (`+placeholders(len(ids))+`)
+		ORDER BY m.tweet_id, m.start_offset`, intArgs(ids)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tweetID int
+		var mention Mention
+		if err := rows.Scan(&tweetID, &mention.UserID, &mention.Username, &mention.Start, &mention.End); err != nil {
+			return err
+		}
+		if tweet := byID[tweetID]; tweet != nil {
+			tweet.Entities.Mentions = append(tweet.Entities.Mentions, mention)
+		}
+	}
+	return rows.Err()
+}
+
+// MentionedUserIDs returns the distinct IDs of the users mentioned in a tweet, in order of first mention
+func (t *Tweet) MentionedUserIDs() []int {
+	seen := map[int]bool{}
+	ids := []int{}
+	for _, mention := range t.Entities.Mentions {
+		if !seen[mention.UserID] {
+			seen[mention.UserID] = true
+			ids = append(ids, mention.UserID)
+		}
+	}
+	return ids
+}
+
+// GetMentions lists the tweets mentioning userID, most recent first
+// Tweets written by users that userID blocks are left out. Only tweets with an ID lower than
+// beforeID are returned (0 means from the start).
+func GetMentions(db *sql.DB, userID int, beforeID int, limit int) ([]*Tweet, error) {
+	return queryTweets(db, tweetSelect+`
+		WHERE EXISTS (SELECT 1 FROM tweet_mentions m WHERE m.tweet_id = t.id AND m.user_id = ?)
+		AND NOT EXISTS (SELECT 1 FROM blocks b WHERE b.blocker_id = ? AND b.blocked_id = t.user_id)
+		AND (? = 0 OR t.id < ?)
+		ORDER BY t.id DESC LIMIT ?`, userID, userID, beforeID, beforeID, limit)
+}
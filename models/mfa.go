@@ -0,0 +1,168 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"errors"       // To define the errors returned by this file
+	"time"         // To work with the confirmation time
+)
+
+// ErrMFAAlreadyEnabled is returned by StartMFAEnrollment when the user already confirmed 2FA
+var ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+
+// UserMFA represents a row in the "user_mfa" table
+// Secret is needed to compute the expected codes, so it is never sent back once enrollment started
+type UserMFA struct {
+	UserID       int        `json:"user_id"`
+	Secret       string     `json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"`
+	LastUsedStep int64      `json:"-"`
+}
+
+// Enabled reports whether 2FA was confirmed, i.e. whether logins require a code
+func (m *UserMFA) Enabled() bool {
+	return m != nil && m.ConfirmedAt != nil
+}
+
+// GetUserMFA retrieves the 2FA settings of a user
+// It returns nil (and no error) when the user never started enrolling
+func GetUserMFA(db *sql.DB, userID int) (*UserMFA, error) {
+	mfa := UserMFA{UserID: userID}
+	var confirmedAt sql.NullTime
+	err := db.QueryRow("SELECT secret, confirmed_at, last_used_step FROM user_mfa WHERE user_id = ?", userID).
+		Scan(&mfa.Secret, &confirmedAt, &mfa.LastUsedStep)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No 2FA
+		}
+		return nil, err
+	}
+	if confirmedAt.Valid {
+		mfa.ConfirmedAt = &confirmedAt.Time
+	}
+	return &mfa, nil
+}
+
+// StartMFAEnrollment stores a new, unconfirmed TOTP secret for a user
+// A previous unconfirmed secret is replaced; a confirmed one is kept and ErrMFAAlreadyEnabled is returned.
+func StartMFAEnrollment(db *sql.DB, userID int, secret string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	if _, err := tx.Exec("DELETE FROM user_mfa WHERE user_id = ? AND confirmed_at IS NULL", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO user_mfa (user_id, secret) VALUES (?, ?)", userID, secret); err != nil {
+		if isDuplicateKeyError(err) {
+			return ErrMFAAlreadyEnabled
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConfirmMFA turns 2FA on after the first valid code, and replaces the recovery codes of the user
+// step is the time step of the code, which can't be used again. It returns false when there is no
+// pending enrollment (never started, or already confirmed).
+func ConfirmMFA(db *sql.DB, userID int, step int64, recoveryCodeHashes []string) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE user_mfa SET confirmed_at = CURRENT_TIMESTAMP, last_used_step = ? WHERE user_id = ? AND confirmed_at IS NULL", step, userID)
+	if err != nil {
+		return false, err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		return false, err
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, recoveryCodeHashes); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// replaceRecoveryCodes removes every recovery code of a user and stores new ones
+func replaceRecoveryCodes(tx *sql.Tx, userID int, codeHashes []string) error {
+	if _, err := tx.Exec("DELETE FROM mfa_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec("INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UseMFAStep records that the code of the given time step was used
+// It returns false when a code of this step (or a later one) was already used, which stops replays.
+// The check and the update are a single statement, so two requests with the same code can't both pass.
+func UseMFAStep(db *sql.DB, userID int, step int64) (bool, error) {
+	result, err := db.Exec("UPDATE user_mfa SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?", step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UseRecoveryCode marks a recovery code as used
+// It returns false when the user has no unused code with this hash
+func UseRecoveryCode(db *sql.DB, userID int, codeHash string) (bool, error) {
+	result, err := db.Exec("UPDATE mfa_recovery_codes SET used_at = CURRENT_TIMESTAMP WHERE user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// CountRecoveryCodes returns how many recovery codes of the user are still unused
+func CountRecoveryCodes(db *sql.DB, userID int) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = ? AND used_at IS NULL", userID).Scan(&count)
+	return count, err
+}
+
+// RegenerateRecoveryCodes replaces every recovery code of a user
+func RegenerateRecoveryCodes(db *sql.DB, userID int, codeHashes []string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DisableMFA turns 2FA off and removes the secret and the recovery codes of a user
+func DisableMFA(db *sql.DB, userID int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM mfa_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("DELETE FROM user_mfa WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
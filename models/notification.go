@@ -0,0 +1,312 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"fmt"          // To build the group keys and the notification messages
+	"strings"      // To build the placeholders of IN (...) clauses
+	"time"         // To work with the time of each notification
+)
+
+// Types of notifications
+const (
+	NotificationLike    = "like"    // Someone liked one of the user's tweets
+	NotificationRetweet = "retweet" // Someone retweeted one of the user's tweets
+	NotificationFollow  = "follow"  // Someone followed the user
+	NotificationReply   = "reply"   // Someone replied to one of the user's tweets
+	NotificationMention = "mention" // Someone mentioned the user in a tweet
+	NotificationQuote   = "quote"   // Someone quoted one of the user's tweets
+)
+
+// notificationActorsShown is how many actors are loaded for each notification
+// The others are only counted, as in "alice, bob and 12 others liked your tweet"
+const notificationActorsShown = 3
+
+// Notification is one entry of a user's notifications inbox
+// Likes, retweets and follows are grouped while unread, so one notification may have many actors.
+type Notification struct {
+	ID         int        `json:"id"`
+	Type       string     `json:"type"`              // One of the Notification* constants
+	Message    string     `json:"message"`           // Human readable summary, e.g. "alice and 12 others liked your tweet"
+	TweetID    *int       `json:"tweet_id"`          // The tweet concerned; nil for follows
+	Tweet      *Tweet     `json:"tweet,omitempty"`   // The tweet itself: the liked tweet, or the reply, mention or quote
+	Actors     []UserRef  `json:"actors"`            // The latest actors, newest first
+	ActorCount int        `json:"actor_count"`       // How many users took part, including those not in Actors
+	Read       bool       `json:"read"`              // Whether the notification was marked as read
+	CreatedAt  time.Time  `json:"created_at"`        // When the first actor took part
+	UpdatedAt  time.Time  `json:"updated_at"`        // When the latest actor took part
+	ReadAt     *time.Time `json:"read_at,omitempty"` // When the notification was marked as read
+}
+
+// NotificationPosition identifies a notification in the inbox order (most recently updated first)
+// It is what pagination cursors are made of: the next page starts right after this position
+type NotificationPosition struct {
+	At int64 `json:"at"` // Update time, in Unix seconds
+	ID int   `json:"id"` // Notification ID
+}
+
+// Position returns where the notification sits in the inbox order
+func (n *Notification) Position() NotificationPosition {
+	return NotificationPosition{At: n.UpdatedAt.Unix(), ID: n.ID}
+}
+
+// notificationGroupKey returns the key shared by the notifications that are grouped together
+// Likes and retweets are grouped per tweet and follows are all grouped; replies, mentions and quotes are never grouped,
+// their key is unique to the tweet so that recording them twice doesn't create a duplicate.
+func notificationGroupKey(kind string, tweetID int) string {
+	if kind == NotificationFollow {
+		return kind
+	}
+	return fmt.Sprintf("%s:%d", kind, tweetID)
+}
+
+// notificationAttempts is how many times RecordNotification tries again when MySQL picks it as a deadlock victim
+const notificationAttempts = 3
+
+// RecordNotification adds an actor to the notifications of a user
+// If an unread notification of the same group exists, the actor joins it and the notification moves
+// to the top of the inbox; otherwise a new notification is created. An actor who is already part of
+// the group (e.g. after unliking and liking again) doesn't change anything.
+// tweetID is 0 for follows.
+func RecordNotification(db *sql.DB, userID int, actorID int, kind string, tweetID int) error {
+	var err error
+	for attempt := 0; attempt < notificationAttempts; attempt++ {
+		if err = recordNotification(db, userID, actorID, kind, tweetID); !isDeadlockError(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// recordNotification makes one attempt at RecordNotification
+func recordNotification(db *sql.DB, userID int, actorID int, kind string, tweetID int) error {
+	var tweet interface{} // NULL when there is no tweet
+	if tweetID != 0 {
+		tweet = tweetID
+	}
+	groupKey := notificationGroupKey(kind, tweetID)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	// Open the group, or find and lock the one already open: open_group_key is the group key until the
+	// notification is read, and UNIQUE (user_id, open_group_key) keeps two events opening the same group
+	// at once from creating two notifications. LAST_INSERT_ID(id) makes LastInsertId return the existing row.
+	// A new group starts without actors; the first one is counted below like the others.
+	result, err := tx.Exec(`INSERT INTO notifications (user_id, type, tweet_id, group_key, open_group_key, actor_count)
+		VALUES (?, ?, ?, ?, ?, 0)
+		ON DUPLICATE KEY UPDATE id = LAST_INSERT_ID(id)`, userID, kind, tweet, groupKey, groupKey)
+	if err != nil {
+		return err
+	}
+	notificationID, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	result, err = tx.Exec("INSERT IGNORE INTO notification_actors (notification_id, actor_id) VALUES (?, ?)", notificationID, actorID)
+	if err != nil {
+		return err
+	}
+	added, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if added == 0 {
+		return nil // Already part of the group
+	}
+	if _, err := tx.Exec(`UPDATE notifications
+		SET actor_count = actor_count + 1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = ?`, notificationID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetNotifications returns the notifications of a user, most recently updated first
+// Pass nil as after to start from the top, and unreadOnly to skip the notifications already read.
+// The tweets and the latest actors of each notification are loaded too.
+func GetNotifications(db *sql.DB, userID int, after *NotificationPosition, unreadOnly bool, limit int) ([]Notification, error) {
+	// Without a cursor the position is ignored and the newest notifications are returned
+	var position NotificationPosition
+	if after != nil {
+		position = *after
+	}
+
+	rows, err := db.Query(`SELECT id, type, tweet_id, actor_count, read_at, created_at, updated_at
+		FROM notifications
+		WHERE user_id = ? AND (? = FALSE OR read_at IS NULL) AND (? OR (updated_at, id) < (?, ?))
+		ORDER BY updated_at DESC, id DESC
+		LIMIT ?`, userID, unreadOnly, after == nil, time.Unix(position.At, 0), position.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	for rows.Next() {
+		var n Notification
+		var tweetID sql.NullInt64
+		var readAt sql.NullTime
+		if err := rows.Scan(&n.ID, &n.Type, &tweetID, &n.ActorCount, &readAt, &n.CreatedAt, &n.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if tweetID.Valid {
+			id := int(tweetID.Int64)
+			n.TweetID = &id
+		}
+		if readAt.Valid {
+			n.ReadAt = &readAt.Time
+			n.Read = true
+		}
+		n.Actors = []UserRef{}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := hydrateNotifications(db, notifications); err != nil {
+		return nil, err
+	}
+	return notifications, nil
+}
+
+// hydrateNotifications loads the tweets and the latest actors of the notifications, and builds their messages
+func hydrateNotifications(db *sql.DB, notifications []Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	ids := make([]interface{}, len(notifications))
+	tweetIDs := []int{}
+	byID := make(map[int]*Notification, len(notifications))
+	for i := range notifications {
+		ids[i] = notifications[i].ID
+		byID[notifications[i].ID] = &notifications[i]
+		if notifications[i].TweetID != nil {
+			tweetIDs = append(tweetIDs, *notifications[i].TweetID)
+		}
+	}
+
+	tweets, err := GetTweetsByIDs(db, tweetIDs)
+	if err != nil {
+		return err
+	}
+
+	// Only the latest few actors of each group are loaded, however big the group is
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	rows, err := db.Query(`SELECT notification_id, id, username FROM (
+			SELECT na.notification_id, u.id, u.username,
+				ROW_NUMBER() OVER (PARTITION BY na.notification_id ORDER BY na.created_at DESC, na.actor_id DESC) AS position
+			FROM notification_actors na
+			JOIN users u ON u.id = na.actor_id AND u.deleted_at IS NULL
+			WHERE na.notification_id IN (`+placeholders+`)
+		) actors
+		WHERE position <= ?
+		ORDER BY notification_id, position`, append(ids, notificationActorsShown)...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var notificationID int
+		var actor UserRef
+		if err := rows.Scan(&notificationID, &actor.ID, &actor.Username); err != nil {
+			return err
+		}
+		if n, ok := byID[notificationID]; ok {
+			n.Actors = append(n.Actors, actor)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for i := range notifications {
+		n := &notifications[i]
+		if n.TweetID != nil {
+			n.Tweet = tweets[*n.TweetID]
+		}
+		n.Message = notificationMessage(n)
+	}
+	return nil
+}
+
+// notificationMessage builds the human readable summary of a notification
+func notificationMessage(n *Notification) string {
+	var action string
+	switch n.Type {
+	case NotificationLike:
+		action = "liked your tweet"
+	case NotificationRetweet:
+		action = "retweeted your tweet"
+	case NotificationFollow:
+		action = "followed you"
+	case NotificationReply:
+		action = "replied to your tweet"
+	case NotificationMention:
+		action = "mentioned you"
+	case NotificationQuote:
+		action = "quoted your tweet"
+	default:
+		action = n.Type
+	}
+
+	// The actors who deleted their account are counted but not named
+	if len(n.Actors) == 0 {
+		if n.ActorCount == 1 {
+			return "Someone " + action
+		}
+		return fmt.Sprintf("%d people %s", n.ActorCount, action)
+	}
+
+	names := n.Actors[0].Username
+	others := n.ActorCount - 1
+	if others == 1 && len(n.Actors) > 1 {
+		return names + " and " + n.Actors[1].Username + " " + action
+	}
+	switch {
+	case others == 1:
+		return names + " and 1 other " + action
+	case others > 1:
+		return fmt.Sprintf("%s and %d others %s", names, others, action)
+	}
+	return names + " " + action
+}
+
+// CountUnreadNotifications returns how many notifications of the user are unread
+func CountUnreadNotifications(db *sql.DB, userID int) (int, error) {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL", userID).Scan(&count)
+	return count, err
+}
+
+// MarkNotificationRead marks one notification of the user as read
+// It returns false when the notification doesn't exist or belongs to someone else.
+// Marking a notification that is already read is not an error.
+func MarkNotificationRead(db *sql.DB, userID int, notificationID int) (bool, error) {
+	var exists bool
+	err := db.QueryRow("SELECT EXISTS(SELECT 1 FROM notifications WHERE id = ? AND user_id = ?)", notificationID, userID).Scan(&exists)
+	if err != nil || !exists {
+		return false, err
+	}
+	_, err = db.Exec("UPDATE notifications SET read_at = CURRENT_TIMESTAMP, open_group_key = NULL WHERE id = ? AND read_at IS NULL", notificationID)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user as read
+// It returns how many notifications were updated
+func MarkAllNotificationsRead(db *sql.DB, userID int) (int64, error) {
+	result, err := db.Exec("UPDATE notifications SET read_at = CURRENT_TIMESTAMP, open_group_key = NULL WHERE user_id = ? AND read_at IS NULL", userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
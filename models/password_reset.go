@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"time"         // To work with the expiration time of the reset token
+)
+
+// PasswordReset represents a row in the "password_resets" table
+// The Token field holds the SHA-256 hash of the token, never the token that was emailed to the user
+type PasswordReset struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"user_id"`
+	Token     string    `json:"-"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiredAt time.Time `json:"expired_at"`
+}
+
+// CreatePasswordReset stores a new hashed reset token for a user
+// Any older reset token of the same user is removed first, so only the latest email can be used
+func CreatePasswordReset(db *sql.DB, userID int, tokenHash string, expiredAt time.Time) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	if _, err := tx.Exec("DELETE FROM password_resets WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("INSERT INTO password_resets (user_id, token, expired_at) VALUES (?, ?, ?)", userID, tokenHash, expiredAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ConsumePasswordReset looks up a reset token by its hash and deletes it in the same transaction
+// This makes the token single-use: two requests racing with the same token can't both succeed.
+// It returns nil (and no error) when the token doesn't exist or has already been used.
+func ConsumePasswordReset(db *sql.DB, tokenHash string) (*PasswordReset, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var reset PasswordReset
+	err = tx.QueryRow("SELECT id, user_id, token, created_at, expired_at FROM password_resets WHERE token = ? FOR UPDATE", tokenHash).
+		Scan(&reset.ID, &reset.UserID, &reset.Token, &reset.CreatedAt, &reset.ExpiredAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // Unknown or already used token
+		}
+		return nil, err
+	}
+
+	if _, err := tx.Exec("DELETE FROM password_resets WHERE id = ?", reset.ID); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &reset, nil
+}
+
+// DeletePasswordResetsForUser removes every pending reset token of a user
+// This is called after a successful reset so that no other link keeps working
+func DeletePasswordResetsForUser(db *sql.DB, userID int) error {
+	_, err := db.Exec("DELETE FROM password_resets WHERE user_id = ?", userID)
+	return err
+}
+
+// Expired reports whether the reset token is past its expiration time
+func (r *PasswordReset) Expired() bool {
+	return time.Now().After(r.ExpiredAt)
+}
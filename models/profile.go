@@ -0,0 +1,67 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"strings"      // To join the SET clauses of the update
+)
+
+// ProfileUpdate holds the profile fields a user wants to change
+// A nil field is left as it is; an empty string clears the field
+type ProfileUpdate struct {
+	DisplayName        *string
+	Bio                *string
+	Location           *string
+	Website            *string
+	AvatarURL          *string
+	BannerURL          *string
+	HideProfileDetails *bool
+}
+
+// HideDetails clears everything but the public card (username, display name, avatar) of a profile whose owner hides its details
+// It is used when the viewer is neither the owner nor one of their followers
+func (p *UserProfile) HideDetails() {
+	p.Bio = ""
+	p.Location = ""
+	p.Website = ""
+	p.BannerURL = ""
+}
+
+// UpdateUserProfile saves the fields of update that are set
+// Nothing is written when no field is set
+func UpdateUserProfile(db *sql.DB, userID int, update ProfileUpdate) error {
+	columns := []string{}
+	args := []interface{}{}
+	set := func(column string, value interface{}) {
+		columns = append(columns, column+" = ?")
+		args = append(args, value)
+	}
+
+	if update.DisplayName != nil {
+		set("display_name", *update.DisplayName)
+	}
+	if update.Bio != nil {
+		set("bio", *update.Bio)
+	}
+	if update.Location != nil {
+		set("location", *update.Location)
+	}
+	if update.Website != nil {
+		set("website", *update.Website)
+	}
+	if update.AvatarURL != nil {
+		set("avatar_url", *update.AvatarURL)
+	}
+	if update.BannerURL != nil {
+		set("banner_url", *update.BannerURL)
+	}
+	if update.HideProfileDetails != nil {
+		set("hide_profile_details", *update.HideProfileDetails)
+	}
+	if len(columns) == 0 {
+		return nil
+	}
+
+	// The column names come from the list above, never from the request
+	_, err := db.Exec("UPDATE users SET "+strings.Join(columns, ", ")+" WHERE id = ?", append(args, userID)...)
+	return err
+}
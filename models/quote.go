@@ -0,0 +1,97 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+)
+
+// Reasons why the embed of a quoted tweet shows a tombstone instead of the tweet
+const (
+	TombstoneDeleted = "deleted" // The quoted tweet, or its author, was deleted
+	TombstoneBlocked = "blocked" // The author of the quoted tweet blocks the viewer
+)
+
+// QuotedTweet is the embed of the tweet a quote tweet quotes
+// Tweet is nil when the quoted tweet can't be shown; Tombstone then tells why. Embeds are only one level deep:
+// when the quoted tweet is itself a quote, only its quoted_tweet_id is set.
+type QuotedTweet struct {
+	ID        int    `json:"id"`                  // The ID of the quoted tweet, kept even when it was deleted
+	Tweet     *Tweet `json:"tweet"`               // The quoted tweet with its author, content, counts, media and entities
+	Tombstone string `json:"tombstone,omitempty"` // TombstoneDeleted or TombstoneBlocked when Tweet is nil
+}
+
+// attachQuotedTweets loads the tweets quoted by several tweets at once and stores their embeds on the quotes
+func attachQuotedTweets(db *sql.DB, tweets ...*Tweet) error {
+	ids := []int{}
+	for _, tweet := range tweets {
+		if tweet.QuotedTweetID != nil {
+			ids = append(ids, *tweet.QuotedTweetID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// The quoted tweets get their media and mentions but not their own quoted tweet, which stops the recursion
+	quoted, err := scanTweets(db, tweetSelect+" WHERE t.id IN ("+placeholders(len(ids))+")", intArgs(ids)...)
+	if err != nil {
+		return err
+	}
+	if err := attachTweetDetails(db, quoted...); err != nil {
+		return err
+	}
+	byID := make(map[int]*Tweet, len(quoted))
+	for _, tweet := range quoted {
+		byID[tweet.ID] = tweet
+	}
+
+	for _, tweet := range tweets {
+		if tweet.QuotedTweetID == nil {
+			continue
+		}
+		embed := &QuotedTweet{ID: *tweet.QuotedTweetID, Tweet: byID[*tweet.QuotedTweetID]}
+		if embed.Tweet == nil {
+			embed.Tombstone = TombstoneDeleted
+		}
+		tweet.QuotedTweet = embed
+	}
+	return nil
+}
+
+// GetQuotes lists the tweets quoting tweetID, most recent first
+// Only quotes with an ID lower than beforeID are returned (0 means from the start)
+func GetQuotes(db *sql.DB, tweetID int, beforeID int, limit int) ([]*Tweet, error) {
+	return queryTweets(db, tweetSelect+" WHERE t.quoted_tweet_id = ? AND (? = 0 OR t.id < ?) ORDER BY t.id DESC LIMIT ?",
+		tweetID, beforeID, beforeID, limit)
+}
+
+// HideBlockedQuotes replaces with a tombstone the quoted tweets whose author blocks viewerID
+// The embeds are shared by every viewer, so this is applied to the tweets of each response just before sending it
+func HideBlockedQuotes(db *sql.DB, viewerID int, tweets ...*Tweet) error {
+	authorIDs := []int{}
+	for _, tweet := range tweets {
+		if tweet != nil && tweet.QuotedTweet != nil && tweet.QuotedTweet.Tweet != nil {
+			authorIDs = append(authorIDs, tweet.QuotedTweet.Tweet.UserID)
+		}
+	}
+	if len(authorIDs) == 0 {
+		return nil
+	}
+
+	blockers, err := GetBlockersAmong(db, viewerID, authorIDs)
+	if err != nil {
+		return err
+	}
+	if len(blockers) == 0 {
+		return nil
+	}
+	for _, tweet := range tweets {
+		if tweet == nil || tweet.QuotedTweet == nil || tweet.QuotedTweet.Tweet == nil {
+			continue
+		}
+		if blockers[tweet.QuotedTweet.Tweet.UserID] {
+			// Copy the embed: the same tweet may be shown to other viewers
+			tweet.QuotedTweet = &QuotedTweet{ID: tweet.QuotedTweet.ID, Tombstone: TombstoneBlocked}
+		}
+	}
+	return nil
+}
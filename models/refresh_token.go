@@ -0,0 +1,103 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"errors"       // To define the errors returned when a refresh token can't be used
+	"time"         // To work with expiration times
+)
+
+// ErrRefreshTokenInvalid is returned when a refresh token is unknown, expired or revoked
+var ErrRefreshTokenInvalid = errors.New("invalid or expired refresh token")
+
+// ErrRefreshTokenReused is returned when a refresh token that was already rotated is presented again.
+// When this happens the whole token family is revoked, because one of the two parties is an attacker.
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+
+// RefreshToken represents a row in the "refresh_tokens" table
+// Only the SHA-256 hash of the token is stored; the token itself is only ever known by the client
+type RefreshToken struct {
+	ID        int          `json:"id"`
+	UserID    int          `json:"user_id"`
+	TokenHash string       `json:"-"`
+	FamilyID  string       `json:"family_id"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	UsedAt    sql.NullTime `json:"-"`
+	RevokedAt sql.NullTime `json:"-"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// CreateRefreshToken stores a new refresh token
+// A new login starts a new family; rotations reuse the family of the token they replace
+func CreateRefreshToken(db *sql.DB, userID int, tokenHash string, familyID string, expiresAt time.Time) error {
+	_, err := db.Exec("INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at) VALUES (?, ?, ?, ?)",
+		userID, tokenHash, familyID, expiresAt)
+	return err
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one in the same family
+// The presented token is marked as used and newTokenHash is stored in its place.
+// It returns the ID of the user the token belongs to.
+func RotateRefreshToken(db *sql.DB, tokenHash string, newTokenHash string, expiresAt time.Time) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	// Lock the row so two concurrent refreshes with the same token can't both succeed
+	var token RefreshToken
+	err = tx.QueryRow("SELECT id, user_id, family_id, expires_at, used_at, revoked_at FROM refresh_tokens WHERE token_hash = ? FOR UPDATE", tokenHash).
+		Scan(&token.ID, &token.UserID, &token.FamilyID, &token.ExpiresAt, &token.UsedAt, &token.RevokedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrRefreshTokenInvalid
+		}
+		return 0, err
+	}
+
+	now := time.Now()
+
+	// A token that was already rotated is being replayed: revoke the whole family
+	if token.UsedAt.Valid {
+		if _, err := tx.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL", now, token.FamilyID); err != nil {
+			return 0, err
+		}
+		if err := tx.Commit(); err != nil {
+			return 0, err
+		}
+		return 0, ErrRefreshTokenReused
+	}
+
+	if token.RevokedAt.Valid || now.After(token.ExpiresAt) {
+		return 0, ErrRefreshTokenInvalid
+	}
+
+	// Mark the presented token as used and store its replacement
+	if _, err := tx.Exec("UPDATE refresh_tokens SET used_at = ? WHERE id = ?", now, token.ID); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec("INSERT INTO refresh_tokens (user_id, token_hash, family_id, expires_at) VALUES (?, ?, ?, ?)",
+		token.UserID, newTokenHash, token.FamilyID, expiresAt); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return token.UserID, nil
+}
+
+// RevokeRefreshTokensForUser revokes every active refresh token of a user
+// This is used when the password changes, so old sessions can't be refreshed anymore
+func RevokeRefreshTokensForUser(db *sql.DB, userID int) error {
+	_, err := db.Exec("UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL", time.Now(), userID)
+	return err
+}
+
+// RevokeRefreshTokenFamily revokes the family the given refresh token belongs to
+// This is used on logout, so the refresh token (and any rotation of it) stops working
+func RevokeRefreshTokenFamily(db *sql.DB, tokenHash string) error {
+	_, err := db.Exec(`UPDATE refresh_tokens SET revoked_at = ?
+		WHERE revoked_at IS NULL AND family_id = (SELECT family_id FROM (SELECT family_id FROM refresh_tokens WHERE token_hash = ?) AS t)`,
+		time.Now(), tokenHash)
+	return err
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"time"         // To work with the suspension time of users
+)
+
+// Roles a user can have, stored in the "role" column of the "users" table and in the "role" claim of access tokens
+const (
+	RoleUser      = "user"      // Every account starts as a regular user
+	RoleModerator = "moderator" // Can suspend users and remove any tweet
+	RoleAdmin     = "admin"     // Can do everything, including changing the role of other users
+)
+
+// Permissions checked by middleware.RequirePermission
+const (
+	PermissionListUsers      = "users:list"    // See every account, with email, role and suspension
+	PermissionSuspendUsers   = "users:suspend" // Suspend and unsuspend accounts
+	PermissionManageRoles    = "users:roles"   // Change the role of an account
+	PermissionDeleteAnyTweet = "tweets:delete" // Remove a tweet written by someone else
+	PermissionUnlockLogins   = "logins:unlock" // Lift the lockout of an account after failed logins
+)
+
+// roleRanks orders the roles: a role has every power of the roles ranked below it
+var roleRanks = map[string]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+// rolePermissions lists the permissions granted to each role
+var rolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleModerator: {
+		PermissionListUsers,
+		PermissionSuspendUsers,
+		PermissionDeleteAnyTweet,
+		PermissionUnlockLogins,
+	},
+	RoleAdmin: {
+		PermissionListUsers,
+		PermissionSuspendUsers,
+		PermissionManageRoles,
+		PermissionDeleteAnyTweet,
+		PermissionUnlockLogins,
+	},
+}
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// RoleAtLeast reports whether role ranks as high as minimum (e.g. an admin is at least a moderator)
+// Unknown roles rank lowest
+func RoleAtLeast(role string, minimum string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank >= roleRanks[minimum]
+}
+
+// RoleOutranks reports whether role ranks strictly higher than other
+// Moderators can only act on accounts they outrank, so they can't suspend each other or an admin
+func RoleOutranks(role string, other string) bool {
+	rank, ok := roleRanks[role]
+	return ok && rank > roleRanks[other]
+}
+
+// RoleHasPermission reports whether the role grants the permission
+func RoleHasPermission(role string, permission string) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// UserSummary is the view of an account shown to moderators
+// It has the private fields (email, role, suspension) but never the password
+type UserSummary struct {
+	ID            int        `json:"id"`
+	Username      string     `json:"username"`
+	Email         string     `json:"email"`
+	EmailVerified bool       `json:"email_verified"`
+	Role          string     `json:"role"`
+	SuspendedAt   *time.Time `json:"suspended_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ListUsers returns accounts that aren't deleted, most recent first, with cursor pagination
+// Only users with an ID lower than beforeID are returned (0 means start from the newest);
+// an empty role returns every role.
+func ListUsers(db *sql.DB, role string, beforeID int, limit int) ([]UserSummary, error) {
+	query := "SELECT id, username, email, email_verified, role, suspended_at, created_at FROM users WHERE deleted_at IS NULL"
+	args := []interface{}{}
+	if role != "" {
+		query += " AND role = ?"
+		args = append(args, role)
+	}
+	if beforeID > 0 {
+		query += " AND id < ?"
+		args = append(args, beforeID)
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []UserSummary{}
+	for rows.Next() {
+		var user UserSummary
+		var suspendedAt sql.NullTime
+		if err := rows.Scan(&user.ID, &user.Username, &user.Email, &user.EmailVerified, &user.Role, &suspendedAt, &user.CreatedAt); err != nil {
+			return nil, err
+		}
+		if suspendedAt.Valid {
+			user.SuspendedAt = &suspendedAt.Time
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// SetUserRole changes the role of a user
+func SetUserRole(db *sql.DB, userID int, role string) error {
+	_, err := db.Exec("UPDATE users SET role = ? WHERE id = ?", role, userID)
+	return err
+}
+
+// SuspendUser suspends an account
+// It returns false (and no error) when the account was already suspended
+func SuspendUser(db *sql.DB, userID int) (bool, error) {
+	result, err := db.Exec("UPDATE users SET suspended_at = CURRENT_TIMESTAMP WHERE id = ? AND suspended_at IS NULL", userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+// UnsuspendUser lifts the suspension of an account
+// It returns false (and no error) when the account wasn't suspended
+func UnsuspendUser(db *sql.DB, userID int) (bool, error) {
+	result, err := db.Exec("UPDATE users SET suspended_at = NULL WHERE id = ? AND suspended_at IS NOT NULL", userID)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
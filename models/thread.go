@@ -0,0 +1,128 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"strings"      // To build the placeholders of IN (...) clauses
+)
+
+// ThreadNode is a tweet of a reply tree together with the replies loaded under it
+// Replies holds at most the requested number of replies per tweet; ReplyCount on the tweet tells whether there are more.
+type ThreadNode struct {
+	Tweet   *Tweet       `json:"tweet"`
+	Replies []ThreadNode `json:"replies"`
+}
+
+// GetAncestors returns the tweets a tweet replies to, from the start of the conversation down to its parent
+// At most limit ancestors are returned (the closest ones). The chain stops at a deleted tweet;
+// ancestors written by deleted users are skipped.
+func GetAncestors(db *sql.DB, tweet *Tweet, limit int) ([]*Tweet, error) {
+	if tweet.InReplyToTweetID == nil {
+		return []*Tweet{}, nil
+	}
+
+	// Walk up the in_reply_to_tweet_id links; depth 1 is the parent
+	rows, err := db.Query(`WITH RECURSIVE chain (id, parent_id, depth) AS (
+			SELECT id, in_reply_to_tweet_id, 1 FROM tweets WHERE id = ?
+			UNION ALL
+			SELECT t.id, t.in_reply_to_tweet_id, c.depth + 1
+			FROM tweets t JOIN chain c ON t.id = c.parent_id
+			WHERE c.depth < ?
+		)
+		SELECT id FROM chain ORDER BY depth DESC`, *tweet.InReplyToTweetID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tweets, err := GetTweetsByIDs(db, ids)
+	if err != nil {
+		return nil, err
+	}
+	ancestors := []*Tweet{}
+	for _, id := range ids {
+		if ancestor, ok := tweets[id]; ok {
+			ancestors = append(ancestors, ancestor)
+		}
+	}
+	return ancestors, nil
+}
+
+// GetReplies returns the direct replies to a tweet, oldest first, with cursor pagination
+// Only replies with an ID greater than afterID are returned (0 means from the first reply)
+func GetReplies(db *sql.DB, tweetID int, afterID int, limit int) ([]*Tweet, error) {
+	return queryTweets(db, tweetSelect+" WHERE t.in_reply_to_tweet_id = ? AND t.id > ? ORDER BY t.id LIMIT ?", tweetID, afterID, limit)
+}
+
+// GetRepliesOf returns the first perParent direct replies (oldest first) of each of several tweets, by parent ID
+// It loads one level of a reply tree with a single query
+func GetRepliesOf(db *sql.DB, parentIDs []int, perParent int) (map[int][]*Tweet, error) {
+	replies := make(map[int][]*Tweet, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return replies, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(parentIDs)), ", ")
+	args := make([]interface{}, 0, len(parentIDs)+1)
+	for _, id := range parentIDs {
+		args = append(args, id)
+	}
+	args = append(args, perParent)
+
+	// Number the replies of each parent and keep the first perParent of each
+	tweets, err := queryTweets(db, tweetSelect+` WHERE t.id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY in_reply_to_tweet_id ORDER BY id) AS position
+				FROM tweets WHERE in_reply_to_tweet_id IN (`+placeholders+`)
+			) numbered WHERE position <= ?
+		) ORDER BY t.id`, args...)
+	if err != nil {
+		return nil, err
+	}
+	for _, tweet := range tweets {
+		replies[*tweet.InReplyToTweetID] = append(replies[*tweet.InReplyToTweetID], tweet)
+	}
+	return replies, nil
+}
+
+// queryTweets runs a query built on tweetSelect and loads what the tweets it returns embed
+func queryTweets(db *sql.DB, query string, args ...interface{}) ([]*Tweet, error) {
+	tweets, err := scanTweets(db, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return tweets, hydrateTweets(db, tweets...)
+}
+
+// scanTweets runs a query built on tweetSelect and returns the bare tweets, without their media or quoted tweet
+func scanTweets(db *sql.DB, query string, args ...interface{}) ([]*Tweet, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tweets := []*Tweet{}
+	for rows.Next() {
+		tweet, err := scanTweet(rows)
+		if err != nil {
+			return nil, err
+		}
+		tweets = append(tweets, tweet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return tweets, nil
+}
@@ -0,0 +1,192 @@
+package models
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"strings"      // To build the placeholders of IN (...) clauses
+	"time"         // To work with the time of each timeline entry
+)
+
+// Kinds of timeline entries
+// They are also part of the sort order, so a retweet and a tweet posted in the same second never compare equal
+const (
+	TimelineKindTweet   = 0 // A tweet posted by the user or someone they follow
+	TimelineKindRetweet = 1 // A retweet by someone the user follows
+)
+
+// TimelinePosition identifies an entry of a timeline in its sort order (newest first)
+// It is what pagination cursors are made of: the next page starts right after this position
+type TimelinePosition struct {
+	At   int64 `json:"at"`   // Activity time, in Unix seconds
+	Kind int   `json:"kind"` // TimelineKindTweet or TimelineKindRetweet
+	ID   int   `json:"id"`   // Tweet ID for tweets, row ID in "retweets" for retweets
+}
+
+// Before reports whether p comes after other in a newest-first timeline
+func (p TimelinePosition) Before(other TimelinePosition) bool {
+	if p.At != other.At {
+		return p.At < other.At
+	}
+	if p.Kind != other.Kind {
+		return p.Kind < other.Kind
+	}
+	return p.ID < other.ID
+}
+
+// TimelineEntry is one row of a home timeline before the tweet itself is loaded
+type TimelineEntry struct {
+	TimelinePosition
+	TweetID  int // The tweet to show
+	SourceID int // The account that put the entry in the timeline: the author of a tweet, or the retweeter
+}
+
+// UserRef is a minimal reference to a user inside another payload
+type UserRef struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// TimelineItem is one entry of a timeline as sent to clients
+type TimelineItem struct {
+	Type        string    `json:"type"`                   // "tweet" or "retweet"
+	Tweet       *Tweet    `json:"tweet"`                  // The tweet itself
+	RetweetedBy *UserRef  `json:"retweeted_by,omitempty"` // Who retweeted it, for retweets
+	ActivityAt  time.Time `json:"activity_at"`            // When the tweet was posted or retweeted
+}
+
+// GetHomeTimelineEntries returns the home timeline of a user, newest first
+// It merges the user's own tweets with the tweets and retweets of the accounts they follow.
+// Pagination uses keyset positions instead of OFFSET, so new tweets don't shift the pages;
+// pass nil to start from the newest entry.
+func GetHomeTimelineEntries(db *sql.DB, userID int, after *TimelinePosition, limit int) ([]TimelineEntry, error) {
+	return queryTimelineEntries(db,
+		"t.user_id = ? OR t.user_id IN (SELECT following_id FROM followers WHERE follower_id = ?)", []interface{}{userID, userID},
+		"r.user_id IN (SELECT following_id FROM followers WHERE follower_id = ?)", []interface{}{userID},
+		after, limit)
+}
+
+// GetSourceTimelineEntries returns the tweets and retweets posted by the given accounts, newest first
+// It is used to pull the activity of accounts that are not fanned out, and to backfill a timeline
+func GetSourceTimelineEntries(db *sql.DB, sourceIDs []int, after *TimelinePosition, limit int) ([]TimelineEntry, error) {
+	if len(sourceIDs) == 0 {
+		return []TimelineEntry{}, nil
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(sourceIDs)), ", ")
+	args := make([]interface{}, len(sourceIDs))
+	for i, id := range sourceIDs {
+		args[i] = id
+	}
+	return queryTimelineEntries(db,
+		"t.user_id IN ("+placeholders+")", args,
+		"r.user_id IN ("+placeholders+")", args,
+		after, limit)
+}
+
+// queryTimelineEntries merges the tweets matching tweetFilter with the retweets matching retweetFilter
+// The filters are SQL conditions on the "tweets t" and "retweets r" aliases, written by this package only
+func queryTimelineEntries(db *sql.DB, tweetFilter string, tweetArgs []interface{}, retweetFilter string, retweetArgs []interface{}, after *TimelinePosition, limit int) ([]TimelineEntry, error) {
+	// Each branch applies the cursor and the limit itself, so it reads at most limit rows from the
+	// (user_id, created_at) indexes instead of every tweet and retweet of the followed accounts
+	tweetCursor, tweetCursorArgs := timelineCursorFilter("t", TimelineKindTweet, after)
+	retweetCursor, retweetCursorArgs := timelineCursorFilter("r", TimelineKindRetweet, after)
+
+	query := `SELECT activity_at, kind, item_id, tweet_id, source_id FROM (
+			(SELECT t.created_at AS activity_at, 0 AS kind, t.id AS item_id, t.id AS tweet_id, t.user_id AS source_id
+			FROM tweets t
+			WHERE (` + tweetFilter + `)` + tweetCursor + `
+			ORDER BY t.created_at DESC, t.id DESC
+			LIMIT ?)
+			UNION ALL
+			(SELECT r.created_at, 1, r.id, r.tweet_id, r.user_id
+			FROM retweets r
+			WHERE (` + retweetFilter + `)` + retweetCursor + `
+			ORDER BY r.created_at DESC, r.id DESC
+			LIMIT ?)
+		) feed
+		ORDER BY activity_at DESC, kind DESC, item_id DESC
+		LIMIT ?`
+
+	args := append([]interface{}{}, tweetArgs...)
+	args = append(args, tweetCursorArgs...)
+	args = append(args, limit)
+	args = append(args, retweetArgs...)
+	args = append(args, retweetCursorArgs...)
+	args = append(args, limit, limit)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []TimelineEntry{}
+	for rows.Next() {
+		var entry TimelineEntry
+		var activityAt time.Time
+		if err := rows.Scan(&activityAt, &entry.Kind, &entry.ID, &entry.TweetID, &entry.SourceID); err != nil {
+			return nil, err
+		}
+		entry.At = activityAt.Unix()
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// HydrateTimeline loads the tweets of the entries and builds the items sent to clients
+// Entries whose tweet or retweeter no longer exists are skipped
+func HydrateTimeline(db *sql.DB, entries []TimelineEntry) ([]TimelineItem, error) {
+	tweetIDs := make([]int, 0, len(entries))
+	retweeterIDs := []int{}
+	for _, entry := range entries {
+		tweetIDs = append(tweetIDs, entry.TweetID)
+		if entry.Kind == TimelineKindRetweet {
+			retweeterIDs = append(retweeterIDs, entry.SourceID)
+		}
+	}
+
+	tweets, err := GetTweetsByIDs(db, tweetIDs)
+	if err != nil {
+		return nil, err
+	}
+	retweeters, err := GetUsernamesByIDs(db, retweeterIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []TimelineItem{}
+	for _, entry := range entries {
+		tweet, ok := tweets[entry.TweetID]
+		if !ok {
+			continue // Deleted since the entry was written
+		}
+		item := TimelineItem{Type: "tweet", Tweet: tweet, ActivityAt: time.Unix(entry.At, 0)}
+		if entry.Kind == TimelineKindRetweet {
+			username, ok := retweeters[entry.SourceID]
+			if !ok {
+				continue // The retweeter deleted their account
+			}
+			item.Type = "retweet"
+			item.RetweetedBy = &UserRef{ID: entry.SourceID, Username: username}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// timelineCursorFilter returns the condition (starting with " AND ") that keeps the rows of one branch of
+// queryTimelineEntries coming after the cursor in the order (activity_at, kind, item_id) DESC, with its arguments.
+// As every row of a branch has the same kind, the row comparison is unrolled into plain comparisons on
+// created_at and id, which the indexes can serve. There is no condition without a cursor.
+func timelineCursorFilter(alias string, kind int, after *TimelinePosition) (string, []interface{}) {
+	if after == nil {
+		return "", nil
+	}
+	at := time.Unix(after.At, 0)
+	switch {
+	case kind < after.Kind:
+		// Rows of a lower kind at the same time come after the cursor
+		return " AND " + alias + ".created_at <= ?", []interface{}{at}
+	case kind > after.Kind:
+		return " AND " + alias + ".created_at < ?", []interface{}{at}
+	}
+	return " AND (" + alias + ".created_at < ? OR (" + alias + ".created_at = ? AND " + alias + ".id < ?))", []interface{}{at, at, after.ID}
+}
@@ -0,0 +1,248 @@
+package models
+
+import (
+	"GO-X/entities" // Import the entities package to find the hashtags of a tweet
+	"database/sql"  // Import the database/sql package to interact with SQL databases
+	"strings"       // To build the placeholders of IN (...) clauses
+	"time"          // To work with the creation and update times of tweets
+)
+
+// Tweet struct represents a tweet in the system
+// Username is not a column of the "tweets" table, it is joined from the author's row in "users"
+type Tweet struct {
+	ID           int       `json:"id"`            // The ID of the tweet, auto-generated in the database
+	UserID       int       `json:"user_id"`       // The ID of the author
+	Username     string    `json:"username"`      // The username of the author
+	Content      string    `json:"content"`       // The text of the tweet
+	LikeCount    int       `json:"like_count"`    // Number of users who liked the tweet
+	RetweetCount int       `json:"retweet_count"` // Number of users who retweeted the tweet
+	ReplyCount   int       `json:"reply_count"`   // Number of direct replies to the tweet
+	QuoteCount   int       `json:"quote_count"`   // Number of tweets quoting the tweet
+	CreatedAt    time.Time `json:"created_at"`    // When the tweet was posted
+	UpdatedAt    time.Time `json:"updated_at"`    // When the tweet was last edited
+	Media        []Media   `json:"media"`         // Attached images, in the order they were given
+
+	InReplyToTweetID *int `json:"in_reply_to_tweet_id"` // The tweet this one answers, nil for a new conversation (or when the parent was deleted)
+	ConversationID   int  `json:"conversation_id"`      // The ID of the first tweet of the conversation; a new conversation uses its own ID
+
+	QuotedTweetID *int         `json:"quoted_tweet_id"` // The tweet this one quotes, nil for a tweet that isn't a quote
+	QuotedTweet   *QuotedTweet `json:"quoted_tweet"`    // The embed of the quoted tweet, nil for a tweet that isn't a quote
+
+	Entities TweetEntities `json:"entities"` // The hashtags and mentions of the content
+}
+
+// tweetSelect is the SELECT used to load tweets together with their author and counters
+// Tweets of soft-deleted users are hidden by the join.
+// The conversation_id column is NULL for the first tweet of a conversation, which is its own conversation.
+const tweetSelect = `SELECT t.id, t.user_id, u.username, t.content,
+		(SELECT COUNT(*) FROM likes l WHERE l.tweet_id = t.id) AS like_count,
+		(SELECT COUNT(*) FROM retweets r WHERE r.tweet_id = t.id) AS retweet_count,
+		(SELECT COUNT(*) FROM tweets rp WHERE rp.in_reply_to_tweet_id = t.id) AS reply_count,
+		(SELECT COUNT(*) FROM tweets q WHERE q.quoted_tweet_id = t.id) AS quote_count,
+		t.in_reply_to_tweet_id, COALESCE(t.conversation_id, t.id) AS conversation_id, t.quoted_tweet_id,
+		t.created_at, t.updated_at
+	FROM tweets t
+	JOIN users u ON u.id = t.user_id AND u.deleted_at IS NULL`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanTweet reads one row produced by tweetSelect
+func scanTweet(row rowScanner) (*Tweet, error) {
+	var tweet Tweet
+	var inReplyTo, quoted sql.NullInt64
+	err := row.Scan(&tweet.ID, &tweet.UserID, &tweet.Username, &tweet.Content, &tweet.LikeCount, &tweet.RetweetCount, &tweet.ReplyCount, &tweet.QuoteCount,
+		&inReplyTo, &tweet.ConversationID, &quoted, &tweet.CreatedAt, &tweet.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if inReplyTo.Valid {
+		id := int(inReplyTo.Int64)
+		tweet.InReplyToTweetID = &id
+	}
+	if quoted.Valid {
+		id := int(quoted.Int64)
+		tweet.QuotedTweetID = &id
+	}
+	tweet.Entities = TweetEntities{Hashtags: entities.ExtractHashtags(tweet.Content), Mentions: []Mention{}}
+	return &tweet, nil
+}
+
+// Create saves a new tweet in the database, together with the hashtags and mentions of its content
+// A reply must have InReplyToTweetID and ConversationID set to the parent's ID and conversation,
+// and a quote must have QuotedTweetID set. On success the ID and timestamps of the tweet are filled in
+func (t *Tweet) Create(db *sql.DB) error {
+	var conversationID interface{} // NULL for a new conversation
+	if t.InReplyToTweetID != nil {
+		conversationID = t.ConversationID
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	result, err := tx.Exec("INSERT INTO tweets (user_id, content, in_reply_to_tweet_id, conversation_id, quoted_tweet_id) VALUES (?, ?, ?, ?, ?)",
+		t.UserID, t.Content, t.InReplyToTweetID, conversationID, t.QuotedTweetID)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	if err := setTweetEntities(tx, int(id), t.Content); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Read the row back to get the timestamps set by the database
+	created, err := GetTweetByID(db, int(id))
+	if err != nil {
+		return err
+	}
+	if created != nil {
+		*t = *created
+	}
+	return nil
+}
+
+// GetTweetByID retrieves a tweet by its ID
+// It returns nil (and no error) when the tweet doesn't exist
+func GetTweetByID(db *sql.DB, id int) (*Tweet, error) {
+	tweet, err := scanTweet(db.QueryRow(tweetSelect+" WHERE t.id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No tweet found
+		}
+		return nil, err
+	}
+	if err := hydrateTweets(db, tweet); err != nil {
+		return nil, err
+	}
+	return tweet, nil
+}
+
+// UpdateContent replaces the text of the tweet, and its hashtags and mentions with those of the new text
+func (t *Tweet) UpdateContent(db *sql.DB, content string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	if _, err := tx.Exec("UPDATE tweets SET content = ? WHERE id = ?", content, t.ID); err != nil {
+		return err
+	}
+	if err := setTweetEntities(tx, t.ID, content); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// Read the row back to get the new update time
+	updated, err := GetTweetByID(db, t.ID)
+	if err != nil {
+		return err
+	}
+	if updated != nil {
+		*t = *updated
+	}
+	return nil
+}
+
+// DeleteTweet removes a tweet
+// Likes, retweets, hashtag links and mentions of the tweet are removed by the ON DELETE CASCADE foreign keys;
+// its media are detached and later removed by the orphaned media garbage collection.
+// Quotes of the tweet are kept and show a tombstone in its place.
+func DeleteTweet(db *sql.DB, id int) error {
+	_, err := db.Exec("DELETE FROM tweets WHERE id = ?", id)
+	return err
+}
+
+// GetTweetsByIDs loads several tweets at once and returns them by ID
+// Tweets that don't exist (or whose author was deleted) are simply missing from the map
+func GetTweetsByIDs(db *sql.DB, ids []int) (map[int]*Tweet, error) {
+	tweets := make(map[int]*Tweet, len(ids))
+	if len(ids) == 0 {
+		return tweets, nil
+	}
+
+	list, err := queryTweets(db, tweetSelect+" WHERE t.id IN ("+placeholders(len(ids))+")", intArgs(ids)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, tweet := range list {
+		tweets[tweet.ID] = tweet
+	}
+	return tweets, nil
+}
+
+// hydrateTweets loads what tweets embed, with one query per kind for the whole list:
+// their media and mentions, and the tweet each quote quotes
+func hydrateTweets(db *sql.DB, tweets ...*Tweet) error {
+	if err := attachTweetDetails(db, tweets...); err != nil {
+		return err
+	}
+	return attachQuotedTweets(db, tweets...)
+}
+
+// attachTweetDetails loads the media and mentions of tweets
+func attachTweetDetails(db *sql.DB, tweets ...*Tweet) error {
+	if err := attachTweetMedia(db, tweets...); err != nil {
+		return err
+	}
+	return attachTweetMentions(db, tweets...)
+}
+
+// placeholders returns the "?, ?, ?" list of an IN (...) clause with n values
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// intArgs converts IDs into query arguments
+func intArgs(ids []int) []interface{} {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
+
+// TweetText is the content of a tweet with when it was posted, for the code that only reads text (e.g. trends)
+type TweetText struct {
+	ID        int
+	Content   string
+	CreatedAt time.Time
+}
+
+// GetTweetTextsSince returns up to limit tweets posted at or after since, in (created_at, id) order
+// Only tweets after (since, afterID) in that order are returned, so pass the time and ID of the last tweet
+// of a page to get the next one (0 for the first page). Tweets of deleted and suspended users are left out.
+func GetTweetTextsSince(db *sql.DB, since time.Time, afterID int, limit int) ([]TweetText, error) {
+	rows, err := db.Query(`SELECT t.id, t.content, t.created_at
+		FROM tweets t
+		JOIN users u ON u.id = t.user_id AND u.deleted_at IS NULL AND u.suspended_at IS NULL
+		WHERE t.created_at >= ? AND (t.created_at > ? OR t.id > ?)
+		ORDER BY t.created_at, t.id LIMIT ?`, since, since, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	texts := []TweetText{}
+	for rows.Next() {
+		var text TweetText
+		if err := rows.Scan(&text.ID, &text.Content, &text.CreatedAt); err != nil {
+			return nil, err
+		}
+		texts = append(texts, text)
+	}
+	return texts, rows.Err()
+}
@@ -2,10 +2,30 @@ package models
 
 import (
 	"database/sql" // Import the database/sql package to interact with SQL databases
+	"strings"      // To build the placeholders of IN (...) clauses
+	"sync"         // To compute the dummy password hash only once
+	"time"         // To work with the soft deletion time of users
 
 	"golang.org/x/crypto/bcrypt" // Import bcrypt package for securely hashing passwords
 )
 
+// ErrPasswordMismatch is returned by GetUserByUsernameAndPassword when the password is wrong
+var ErrPasswordMismatch = bcrypt.ErrMismatchedHashAndPassword
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash returns a bcrypt hash (of a random password) with the same cost as real password hashes
+// It is computed once, the first time a login names an unknown user
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte(time.Now().String()), bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
+
 // User struct represents a user in the system
 // This is a Go struct that holds user information
 // The struct tags `json:"username"` are used to specify how the struct fields should be named when converted to or from JSON
@@ -13,20 +33,56 @@ type User struct {
 	ID       int    `json:"id"`       // The ID of the user, typically auto-generated in the database
 	Username string `json:"username"` // The username of the user, unique in the system
 	Email    string `json:"email"`    // The email address of the user
-	Password string `json:"password"` // The password of the user (should be hashed before storing)
+	Password string `json:"-"`        // The password hash of the user, never sent in responses
+
+	EmailVerified bool       `json:"email_verified"` // Whether the user clicked the link sent to their email address
+	Role          string     `json:"role"`           // RoleUser, RoleModerator or RoleAdmin (see role.go)
+	SuspendedAt   *time.Time `json:"suspended_at"`   // Set while a moderator has suspended the account
+}
+
+// Suspended reports whether the account is suspended, i.e. whether the user may not log in
+func (u *User) Suspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// userColumns selects the fields of User from the "users" table
+const userColumns = "id, username, email, password, email_verified, role, suspended_at"
+
+// scanUser reads one row selected with userColumns (plus any extra destinations, e.g. deleted_at)
+func scanUser(row rowScanner, extra ...interface{}) (*User, error) {
+	var user User
+	var suspendedAt sql.NullTime
+	dest := append([]interface{}{&user.ID, &user.Username, &user.Email, &user.Password, &user.EmailVerified, &user.Role, &suspendedAt}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if suspendedAt.Valid {
+		user.SuspendedAt = &suspendedAt.Time
+	}
+	return &user, nil
 }
 
 // Register a new user in the database
 // This function is responsible for saving a new user's information into the "users" table in the database
 func (u *User) Register(db *sql.DB) error {
 	// The SQL query to insert the new user into the "users" table
-	// It takes the username, email, and password from the User struct and inserts them into the table
-	query := `INSERT INTO users (username, email, password) VALUES (?, ?, ?)`
-	_, err := db.Exec(query, u.Username, u.Email, u.Password) // Execute the query
+	// It takes the username, email, password and role from the User struct and inserts them into the table
+	// New accounts are regular users unless a role was set
+	if u.Role == "" {
+		u.Role = RoleUser
+	}
+	query := `INSERT INTO users (username, email, password, role) VALUES (?, ?, ?, ?)`
+	result, err := db.Exec(query, u.Username, u.Email, u.Password, u.Role) // Execute the query
 	if err != nil {
 		// If there’s an error with the query (e.g., a database issue), return the error
 		return err
 	}
+	// Store the auto-generated ID on the struct so the caller can use it right away
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	u.ID = int(id)
 	// If successful, return nil (no error)
 	return nil
 }
@@ -57,15 +113,15 @@ func HashPassword(password string) (string, error) {
 // GetUserByUsername retrieves a user by their username
 // This function queries the database to find a user by their username
 func GetUserByUsernameAndPassword(db *sql.DB, username string, password string) (*User, error) {
-	var user User // Declare a User variable to hold the data from the database
-
 	// Execute a SQL query to select the user's details from the "users" table based on the username
-	err := db.QueryRow("SELECT id, username, email, password FROM users WHERE username = ?", username).
-		Scan(&user.ID, &user.Username, &user.Email, &user.Password)
+	user, err := scanUser(db.QueryRow("SELECT "+userColumns+" FROM users WHERE username = ? AND deleted_at IS NULL", username))
 	if err != nil {
 		// If no user is found (i.e., `sql.ErrNoRows`), return nil to indicate no user exists with that username
 		// If there’s another error (e.g., database issue), return the error
 		if err == sql.ErrNoRows {
+			// Still run a bcrypt comparison, so unknown usernames take as long as wrong passwords
+			// and can't be discovered by timing the response
+			bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
 			return nil, nil // No user found
 		}
 		return nil, err // Return any other database error
@@ -78,5 +134,166 @@ func GetUserByUsernameAndPassword(db *sql.DB, username string, password string)
 	}
 
 	// Return the user found in the database
-	return &user, nil
+	return user, nil
+}
+
+// GetUserByEmail retrieves a user by their email address
+// It returns nil (and no error) when no user has that email
+func GetUserByEmail(db *sql.DB, email string) (*User, error) {
+	user, err := scanUser(db.QueryRow("SELECT "+userColumns+" FROM users WHERE email = ? AND deleted_at IS NULL", email))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No user found
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// UpdatePassword replaces the stored password of a user
+// The password passed in must already be hashed with HashPassword
+func UpdatePassword(db *sql.DB, userID int, hashedPassword string) error {
+	_, err := db.Exec("UPDATE users SET password = ? WHERE id = ?", hashedPassword, userID)
+	return err
+}
+
+// GetUserByID retrieves a user by their ID
+// It returns nil (and no error) when the user doesn't exist
+func GetUserByID(db *sql.DB, id int) (*User, error) {
+	user, err := scanUser(db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = ? AND deleted_at IS NULL", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No user found
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// GetUserByUsername retrieves a user by their username, without checking the password
+// It returns nil (and no error) when the user doesn't exist
+func GetUserByUsername(db *sql.DB, username string) (*User, error) {
+	user, err := scanUser(db.QueryRow("SELECT "+userColumns+" FROM users WHERE username = ? AND deleted_at IS NULL", username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil // No user found
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+// DeleteUser permanently removes a user
+// Tweets, likes, retweets, followers and tokens are removed by the ON DELETE CASCADE foreign keys
+func DeleteUser(db *sql.DB, userID int) error {
+	_, err := db.Exec("DELETE FROM users WHERE id = ?", userID)
+	return err
+}
+
+// SoftDeleteUser marks a user as deleted without removing any data
+// The account can be restored with RestoreUser until it is purged by PurgeDeletedUsers
+func SoftDeleteUser(db *sql.DB, userID int) error {
+	_, err := db.Exec("UPDATE users SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL", time.Now(), userID)
+	return err
+}
+
+// GetDeletedUserByUsernameAndPassword retrieves a soft-deleted user and checks their password
+// It also returns the time the account was deleted, so the caller can check the grace period
+func GetDeletedUserByUsernameAndPassword(db *sql.DB, username string, password string) (*User, time.Time, error) {
+	var deletedAt time.Time
+	user, err := scanUser(db.QueryRow("SELECT "+userColumns+", deleted_at FROM users WHERE username = ? AND deleted_at IS NOT NULL", username), &deletedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, time.Time{}, nil // No deleted user with that username
+		}
+		return nil, time.Time{}, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		return nil, time.Time{}, err // Passwords don't match
+	}
+	return user, deletedAt, nil
+}
+
+// RestoreUser clears the soft deletion of a user
+func RestoreUser(db *sql.DB, userID int) error {
+	_, err := db.Exec("UPDATE users SET deleted_at = NULL WHERE id = ?", userID)
+	return err
+}
+
+// PurgeDeletedUsers permanently removes users soft-deleted before the given time
+// It returns the number of accounts that were removed
+func PurgeDeletedUsers(db *sql.DB, before time.Time) (int64, error) {
+	result, err := db.Exec("DELETE FROM users WHERE deleted_at IS NOT NULL AND deleted_at < ?", before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetUsernamesByIDs returns the usernames of several users at once, by ID
+// Users that don't exist (or were deleted) are missing from the map
+func GetUsernamesByIDs(db *sql.DB, ids []int) (map[int]string, error) {
+	usernames := make(map[int]string, len(ids))
+	if len(ids) == 0 {
+		return usernames, nil
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+
+	rows, err := db.Query("SELECT id, username FROM users WHERE deleted_at IS NULL AND id IN ("+placeholders+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var username string
+		if err := rows.Scan(&id, &username); err != nil {
+			return nil, err
+		}
+		usernames[id] = username
+	}
+	return usernames, rows.Err()
+}
+
+// GetAllUserIDs returns the ID of every user that isn't deleted
+func GetAllUserIDs(db *sql.DB) ([]int, error) {
+	rows, err := db.Query("SELECT id FROM users WHERE deleted_at IS NULL ORDER BY id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// MarkEmailVerified records that the user proved they own their email address
+func MarkEmailVerified(db *sql.DB, userID int) error {
+	_, err := db.Exec("UPDATE users SET email_verified = TRUE WHERE id = ?", userID)
+	return err
+}
+
+// IsEmailVerified reports whether the user with the given username verified their email address
+// It returns false (and no error) when the user doesn't exist
+func IsEmailVerified(db *sql.DB, username string) (bool, error) {
+	var verified bool
+	err := db.QueryRow("SELECT email_verified FROM users WHERE username = ? AND deleted_at IS NULL", username).Scan(&verified)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return verified, err
 }
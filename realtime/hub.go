@@ -0,0 +1,303 @@
+package realtime
+
+import (
+	"sync" // To protect the subscriptions from concurrent access
+	"time" // To timestamp events
+)
+
+// Types of events pushed to clients
+const (
+	EventTweet   = "tweet"   // Someone the user follows posted a tweet
+	EventLike    = "like"    // Someone liked one of the user's tweets
+	EventRetweet = "retweet" // Someone retweeted one of the user's tweets
+	EventFollow  = "follow"  // Someone followed the user
+	EventMention = "mention" // Someone mentioned the user in a tweet
+	EventReply   = "reply"   // Someone replied to one of the user's tweets
+	EventQuote   = "quote"   // Someone quoted one of the user's tweets
+)
+
+// Event is a notification pushed to a connected user
+type Event struct {
+	ID        uint64      `json:"id"`         // Increasing per user, assigned by the hub; used to resume a stream
+	Type      string      `json:"type"`       // One of the Event* constants
+	Data      interface{} `json:"data"`       // The payload, e.g. the tweet and the user who liked it
+	CreatedAt time.Time   `json:"created_at"` // When the event happened
+}
+
+// NewEvent creates an event of the given type happening now
+func NewEvent(eventType string, data interface{}) Event {
+	return Event{Type: eventType, Data: data, CreatedAt: time.Now()}
+}
+
+// SlowClientPolicy decides what happens when a subscriber doesn't read its events fast enough
+type SlowClientPolicy int
+
+const (
+	// DropOldest discards the oldest queued event to make room for the new one
+	DropOldest SlowClientPolicy = iota
+	// Disconnect closes the subscription; the client has to reconnect (and resync) on its own
+	Disconnect
+)
+
+// Subscription receives the events published to one user
+// A user may have several subscriptions at once, e.g. one per open tab
+type Subscription struct {
+	userID  int
+	events  chan Event
+	done    chan struct{}
+	once    sync.Once
+	mu      sync.Mutex // Serializes the "drop oldest, then push" sequence of concurrent publishers
+	dropped int
+	hub     *Hub
+}
+
+// Events returns the channel the subscriber reads its events from
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Done is closed when the subscription ends, either through Close or because the hub disconnected a slow client
+func (s *Subscription) Done() <-chan struct{} {
+	return s.done
+}
+
+// Dropped returns how many events were discarded because the subscriber was too slow
+func (s *Subscription) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+// Close removes the subscription from the hub
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// Hub routes published events to the subscriptions of each user
+// Every subscription has a bounded buffer, so a slow client never blocks the publishers.
+// The hub also remembers the last few events of each user, so a client that reconnects can
+// resume from the last event it received (see SubscribeSince).
+// Only users who are connected, or were in the last ReplayTTL, have a state: events published to
+// anybody else are dropped, so the hub doesn't grow with every user who ever received an event.
+type Hub struct {
+	// ReplayTTL is how long the replay buffer of a user is kept after their last subscription closed
+	ReplayTTL time.Duration
+
+	mu         sync.Mutex
+	users      map[int]*userState
+	bufferSize int
+	replaySize int
+	policy     SlowClientPolicy
+}
+
+// userState holds the subscriptions and the replay buffer of one user
+type userState struct {
+	subscriptions map[*Subscription]struct{}
+	lastID        uint64    // ID of the last event published to the user
+	history       []Event   // The last replaySize events, oldest first
+	idleSince     time.Time // When the last subscription closed; zero while the user is connected
+}
+
+// expired reports whether the state has no subscription and was idle for longer than ttl
+func (s *userState) expired(now time.Time, ttl time.Duration) bool {
+	return len(s.subscriptions) == 0 && !s.idleSince.IsZero() && now.Sub(s.idleSince) >= ttl
+}
+
+// NewHub creates a hub whose subscriptions buffer up to bufferSize events
+// The last replaySize events of each user are kept for resuming; policy decides what happens when a buffer is full.
+func NewHub(bufferSize int, replaySize int, policy SlowClientPolicy) *Hub {
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+	return &Hub{
+		ReplayTTL:  5 * time.Minute,
+		users:      make(map[int]*userState),
+		bufferSize: bufferSize,
+		replaySize: replaySize,
+		policy:     policy,
+	}
+}
+
+// user returns the state of a user, creating it if needed; the hub lock must be held
+func (h *Hub) user(userID int) *userState {
+	state, ok := h.users[userID]
+	if !ok {
+		// IDs start from the clock, so they keep increasing when a state is dropped and created again:
+		// a client resuming from an ID of the old state then gets the whole new buffer
+		state = &userState{subscriptions: make(map[*Subscription]struct{}), lastID: uint64(time.Now().UnixMilli())}
+		h.users[userID] = state
+	}
+	return state
+}
+
+// activeUser returns the state of a user who is connected or was recently, or nil; the hub lock must be held
+// An expired state is dropped on the way.
+func (h *Hub) activeUser(userID int, now time.Time) *userState {
+	state, ok := h.users[userID]
+	if !ok {
+		return nil
+	}
+	if state.expired(now, h.ReplayTTL) {
+		delete(h.users, userID)
+		return nil
+	}
+	return state
+}
+
+// Subscribe starts receiving the events published to the user
+// The caller must call Close on the subscription when it's done with it
+func (h *Hub) Subscribe(userID int) *Subscription {
+	sub, _ := h.SubscribeSince(userID, 0, false)
+	return sub
+}
+
+// SubscribeSince starts receiving the events of the user and, when resume is set, also returns
+// the buffered events published after lastEventID. Both happen atomically, so no event is lost
+// or delivered twice between the replay and the live stream.
+// If lastEventID is unknown (e.g. the server restarted), every buffered event is returned.
+func (h *Hub) SubscribeSince(userID int, lastEventID uint64, resume bool) (*Subscription, []Event) {
+	sub := &Subscription{
+		userID: userID,
+		events: make(chan Event, h.bufferSize),
+		done:   make(chan struct{}),
+		hub:    h,
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	state := h.activeUser(userID, time.Now())
+	if state == nil {
+		state = h.user(userID)
+	}
+	state.subscriptions[sub] = struct{}{}
+	state.idleSince = time.Time{}
+
+	missed := []Event{}
+	if resume {
+		if lastEventID > state.lastID {
+			lastEventID = 0 // An ID from before a restart: replay everything we have
+		}
+		for _, event := range state.history {
+			if event.ID > lastEventID {
+				missed = append(missed, event)
+			}
+		}
+	}
+	return sub, missed
+}
+
+// Publish sends an event to every subscription of the user
+// The event gets the next ID of the user and is kept in the replay buffer. Users who aren't connected,
+// and weren't in the last ReplayTTL, are skipped. It never blocks: full buffers are handled according
+// to the hub's SlowClientPolicy.
+func (h *Hub) Publish(userID int, event Event) {
+	h.mu.Lock()
+	state := h.activeUser(userID, time.Now())
+	if state == nil {
+		h.mu.Unlock()
+		return
+	}
+	state.lastID++
+	event.ID = state.lastID
+	if h.replaySize > 0 {
+		state.history = append(state.history, event)
+		if len(state.history) > h.replaySize {
+			state.history = state.history[len(state.history)-h.replaySize:]
+		}
+	}
+	subs := make([]*Subscription, 0, len(state.subscriptions))
+	for sub := range state.subscriptions {
+		subs = append(subs, sub)
+	}
+	h.mu.Unlock()
+
+	for _, sub := range subs {
+		h.deliver(sub, event)
+	}
+}
+
+// PublishMany sends the same event to several users
+func (h *Hub) PublishMany(userIDs []int, event Event) {
+	for _, userID := range userIDs {
+		h.Publish(userID, event)
+	}
+}
+
+// Connected reports whether the user has at least one subscription
+func (h *Hub) Connected(userID int) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state, ok := h.users[userID]
+	return ok && len(state.subscriptions) > 0
+}
+
+// deliver queues the event on one subscription, applying the slow client policy when its buffer is full
+func (h *Hub) deliver(sub *Subscription, event Event) {
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+
+	select {
+	case <-sub.done:
+		return // Already closed
+	default:
+	}
+
+	select {
+	case sub.events <- event:
+		return
+	default:
+	}
+
+	// The buffer is full
+	sub.dropped++
+	if h.policy == Disconnect {
+		go h.remove(sub) // remove takes the hub lock, don't hold the subscription lock while waiting for it
+		return
+	}
+
+	// DropOldest: make room by discarding the oldest queued event
+	select {
+	case <-sub.events:
+	default:
+	}
+	select {
+	case sub.events <- event:
+	default:
+	}
+}
+
+// Prune drops the states of the users whose replay buffer aged out
+// Publish and SubscribeSince already skip such states; Prune frees those of users who get no more events.
+func (h *Hub) Prune() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := time.Now()
+	for userID, state := range h.users {
+		if state.expired(now, h.ReplayTTL) {
+			delete(h.users, userID)
+		}
+	}
+}
+
+// remove unregisters a subscription and closes its Done channel
+// Once the user has no subscription left, their replay buffer is kept for ReplayTTL (and dropped right away
+// if there is nothing to replay).
+func (h *Hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	if state, ok := h.users[sub.userID]; ok {
+		if _, subscribed := state.subscriptions[sub]; subscribed {
+			delete(state.subscriptions, sub)
+			if len(state.subscriptions) == 0 {
+				state.idleSince = time.Now()
+				if h.replaySize <= 0 || h.ReplayTTL <= 0 {
+					delete(h.users, sub.userID)
+				}
+			}
+		}
+	}
+	h.mu.Unlock()
+
+	sub.once.Do(func() { close(sub.done) })
+}
@@ -3,21 +3,151 @@ package routes
 import (
 	"GO-X/controllers" // Import the controllers package where the logic for handling user requests is defined
 	"GO-X/middleware"  // Import the middleware package for adding additional functionality (e.g., security or authentication)
+	"GO-X/models"      // Import the models package for the roles and permissions checked by the admin routes
 	"database/sql"     // Import the sql package to interact with the database
+	"time"             // Import the time package to express rate limit windows
 
-	"github.com/gofiber/fiber/v2" // Import the Fiber web framework to handle HTTP requests
+	"github.com/gofiber/contrib/websocket" // Import the Fiber WebSocket middleware to upgrade connections
+	"github.com/gofiber/fiber/v2"          // Import the Fiber web framework to handle HTTP requests
+	"github.com/golang-jwt/jwt/v4"         // Import the JWT library for the claims type
 )
 
 // SetupRoutes sets up the routes and accepts the *sql.DB for database access
 // This function defines all the HTTP routes that the application will handle and connects the database
 func SetupRoutes(app *fiber.App, db *sql.DB) {
+	// Rate limits
+	// The authentication routes are limited per client IP and per route, so passwords can't be brute-forced;
+	// the other routes share one budget per authenticated user, with bursts allowed by a token bucket.
+	authLimit := middleware.RateLimit(middleware.RateLimitConfig{
+		Name:  "auth",
+		Limit: middleware.Limit{Algorithm: middleware.SlidingWindow, Requests: 10, Window: time.Minute},
+		Key:   middleware.CombineKeys(middleware.KeyByRoute, middleware.KeyByIP),
+	})
+	userLimit := middleware.RateLimit(middleware.RateLimitConfig{
+		Name:  "api",
+		Limit: middleware.Limit{Algorithm: middleware.TokenBucket, Requests: 120, Window: time.Minute},
+		Key:   middleware.KeyByUser,
+	})
+
 	// Post route for user registration
 	// This route listens for POST requests to /auth/register and calls the RegisterUser function from the controllers package
-	app.Post("/auth/register", controllers.RegisterUser)
+	app.Post("/auth/register", authLimit, controllers.RegisterUser)
+
+	app.Post("/auth/login", authLimit, controllers.LoginUser)
+
+	// Exchange a refresh token for a new access token (the refresh token is rotated on every use)
+	app.Post("/auth/refresh", authLimit, controllers.RefreshToken)
+
+	// Logout routes (require JWT)
+	// The first one revokes the current token, the second one every token of the user ("log out everywhere")
+	app.Post("/auth/logout", middleware.ProtectRoute, controllers.LogoutUser)
+	app.Post("/auth/logout-all", middleware.ProtectRoute, controllers.LogoutAllSessions)
+
+	// Email verification: the link sent after registration, and a way to get a new one (requires JWT)
+	app.Get("/auth/verify-email", authLimit, controllers.VerifyEmail)
+	app.Post("/auth/resend-verification", middleware.ProtectRoute, authLimit, controllers.ResendEmailVerification)
+
+	// Two-factor authentication (TOTP)
+	// Enrollment needs a session; /verify finishes a login with the mfa_token returned by /auth/login,
+	// so it is registered before the group and doesn't go through ProtectRoute
+	app.Post("/auth/mfa/verify", authLimit, controllers.VerifyMFA)
+	mfa := app.Group("/auth/mfa", middleware.ProtectRoute, userLimit)
+	mfa.Post("/enroll", controllers.EnrollMFA)
+	mfa.Post("/confirm", controllers.ConfirmMFA)
+	mfa.Post("/recovery-codes", controllers.RegenerateRecoveryCodes)
+	mfa.Post("/disable", controllers.DisableMFA)
+
+	// Password recovery routes
+	// The first one emails a single-use reset token, the second one uses it to set a new password
+	app.Post("/auth/forgot-password", authLimit, controllers.ForgotPassword)
+	app.Post("/auth/reset-password", authLimit, controllers.ResetPassword)
+
+	// Account restoration during the deletion grace period
+	app.Post("/auth/restore-account", authLimit, controllers.RestoreUser)
+
+	// Tweet routes (require JWT)
+	// Every route of the group goes through ProtectRoute, then the rate limiter, before reaching the controller
+	tweets := app.Group("/tweets", middleware.ProtectRoute, userLimit)
+	// Posting requires a verified email address
+	tweets.Post("/", middleware.RequireVerifiedEmail(db), controllers.CreateTweet)
+	tweets.Get("/:id", controllers.GetTweet)
+	tweets.Patch("/:id", controllers.UpdateTweet)
+	tweets.Delete("/:id", controllers.DeleteTweet)
+
+	// Likes and retweets, idempotent: liking twice or unliking a tweet that isn't liked is not an error
+	tweets.Post("/:id/like", controllers.LikeTweet)
+	tweets.Post("/:id/unlike", controllers.UnlikeTweet)
+	tweets.Post("/:id/retweet", controllers.RetweetTweet)
+	tweets.Post("/:id/unretweet", controllers.UnretweetTweet)
+	tweets.Get("/:id/likes", controllers.GetTweetLikes)
+	tweets.Get("/:id/retweets", controllers.GetTweetRetweets)
+	// Conversation view: the tweets above this one and a paginated tree of replies (?cursor=, ?limit=, ?depth=)
+	tweets.Get("/:id/thread", controllers.GetThread)
+	// Tweets quoting this one, newest first (?cursor=, ?limit=); quotes are posted with "quoted_tweet_id"
+	tweets.Get("/:id/quotes", controllers.GetTweetQuotes)
+
+	// Media uploads (require JWT and a verified email): images sent as multipart forms, attached to tweets with "media_ids"
+	app.Post("/media", middleware.ProtectRoute, userLimit, middleware.RequireVerifiedEmail(db), controllers.UploadMedia)
+	// The files of media attached to tweets are public, so they can be used directly as image sources
+	app.Get("/media/:id/file", controllers.GetMediaFile)
+
+	// User routes (require JWT)
+	users := app.Group("/users", middleware.ProtectRoute, userLimit)
+	// Own profile and account; "/me" is registered before "/:username" so it isn't taken for a username
+	users.Patch("/me", controllers.UpdateProfile)
+	// Tweets mentioning the authenticated user, newest first (?cursor=, ?limit=)
+	users.Get("/me/mentions", controllers.GetMyMentions)
+	// Account deletion (asks for the password again)
+	users.Delete("/me", controllers.RemoveUser)
+	// Follow graph: users can't follow themselves, and lists are paginated with ?cursor= and ?limit=
+	users.Post("/:id/follow", controllers.FollowUser)
+	users.Post("/:id/unfollow", controllers.UnfollowUser)
+	users.Get("/:id/followers", controllers.GetFollowers)
+	users.Get("/:id/following", controllers.GetFollowing)
+	// Blocks: blocking removes the follows both ways and hides the blocker's tweets from the blocked user's quotes
+	users.Post("/:id/block", controllers.BlockUser)
+	users.Post("/:id/unblock", controllers.UnblockUser)
+	// Public profile; the details of private accounts are only shown to their followers
+	users.Get("/:username", controllers.GetUserProfile)
+
+	// Admin routes (require JWT and a role with the right permission)
+	// The whole group is closed to regular users; each route then checks its own permission
+	// (moderators can list, suspend and unlock users and remove tweets, only admins can change roles)
+	admin := app.Group("/admin", middleware.ProtectRoute, userLimit, middleware.RequireRole(models.RoleModerator))
+	admin.Get("/users", middleware.RequirePermission(models.PermissionListUsers), controllers.AdminListUsers)
+	admin.Post("/users/:id/suspend", middleware.RequirePermission(models.PermissionSuspendUsers), controllers.SuspendUser)
+	admin.Post("/users/:id/unsuspend", middleware.RequirePermission(models.PermissionSuspendUsers), controllers.UnsuspendUser)
+	admin.Post("/users/:id/unlock", middleware.RequirePermission(models.PermissionUnlockLogins), controllers.UnlockUser)
+	admin.Put("/users/:id/role", middleware.RequirePermission(models.PermissionManageRoles), controllers.SetUserRole)
+	admin.Delete("/tweets/:id", middleware.RequirePermission(models.PermissionDeleteAnyTweet), controllers.AdminDeleteTweet)
+
+	// Home timeline (requires JWT), paginated with the opaque ?cursor= returned by the previous page
+	app.Get("/timeline/home", middleware.ProtectRoute, userLimit, controllers.GetHomeTimeline)
+	// Recompute the caller's materialized home timeline (repairs timelines that missed updates)
+	app.Post("/timeline/home/rebuild", middleware.ProtectRoute, userLimit, controllers.RebuildHomeTimeline)
+
+	// Hashtag timelines (require JWT): the tweets carrying a tag, newest first (?cursor=, ?limit=)
+	app.Get("/hashtags/:tag", middleware.ProtectRoute, userLimit, controllers.GetHashtagTweets)
+
+	// Trending hashtags and terms (requires JWT), as last computed by the background refresh
+	app.Get("/trends", middleware.ProtectRoute, userLimit, controllers.GetTrends)
+
+	// Notifications inbox (requires JWT); the fixed paths are registered before "/:id/..."
+	notifications := app.Group("/notifications", middleware.ProtectRoute, userLimit)
+	notifications.Get("/", controllers.GetNotifications)
+	notifications.Get("/unread-count", controllers.GetUnreadNotificationCount)
+	notifications.Post("/read-all", controllers.MarkAllNotificationsRead)
+	notifications.Post("/:id/read", controllers.MarkNotificationRead)
+
+	// Real-time events over WebSockets (requires JWT, in the Authorization header or as the "bearer" subprotocol)
+	app.Get("/ws", controllers.RequireHub, middleware.ProtectWebSocket, websocket.New(controllers.StreamWebSocket,
+		websocket.Config{Subprotocols: []string{middleware.WebSocketProtocol}}))
 
-	app.Post("/auth/login", controllers.LoginUser)
+	// The same events as Server-Sent Events, for clients behind proxies that break WebSockets (requires JWT)
+	app.Get("/stream/events", middleware.ProtectRoute, userLimit, controllers.StreamEvents)
 
-	// app.Post("/auth/forgot_password", controllers.forgot-password)
+	// Public signing keys, so other services can verify the tokens issued by this API
+	app.Get("/.well-known/jwks.json", controllers.GetJWKS)
 
 	// Route to check if the API is working
 	// This route listens for GET requests to /api and sends a welcome message as a response
@@ -42,7 +172,7 @@ func SetupRoutes(app *fiber.App, db *sql.DB) {
 	// This route listens for GET requests to /protected and checks if the user is authenticated using JWT (JSON Web Token)
 	app.Get("/protected", middleware.ProtectRoute, func(c *fiber.Ctx) error {
 		// Access the claims (user information) from the JWT token stored in the request context
-		claims := c.Locals("claims").(map[string]interface{})
+		claims := c.Locals("claims").(jwt.MapClaims)
 		// Return the username from the JWT claims in a JSON response
 		return c.JSON(fiber.Map{
 			"status": "success",
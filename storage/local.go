@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"context"       // Part of the Store interface, unused by the local disk
+	"io"            // To copy the uploaded bytes to disk
+	"os"            // To create, open and remove files
+	"path/filepath" // To turn keys into paths under the root directory
+)
+
+// LocalStore is a Store that keeps files in a directory of the local filesystem
+// It is the default backend and is fine for a single server; use S3Store when several servers share the files
+type LocalStore struct {
+	root string // The directory every key is relative to
+}
+
+// NewLocalStore creates a LocalStore writing under the given directory, which is created if needed
+func NewLocalStore(root string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	return &LocalStore{root: root}, nil
+}
+
+// path returns the location of a key on disk
+func (s *LocalStore) path(key string) (string, error) {
+	if err := checkKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.root, filepath.FromSlash(key)), nil
+}
+
+// Put writes the file to a temporary name first, then renames it,
+// so a reader never sees a half-written file
+func (s *LocalStore) Put(ctx context.Context, key string, contentType string, body io.Reader, size int64) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name()) // Does nothing once the file was renamed
+
+	if _, err := io.CopyN(file, body, size); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Get opens the file stored under key
+func (s *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+// Delete removes the file stored under key
+func (s *LocalStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
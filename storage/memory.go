@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"bytes"   // To serve stored files as readers
+	"context" // Part of the Store interface, unused in memory
+	"io"      // To read the uploaded bytes
+	"sync"    // To protect the files from concurrent access
+)
+
+// MemoryStore is a Store that keeps every file in memory
+// It is meant for tests, where nothing should touch the disk or the network
+type MemoryStore struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{files: make(map[string][]byte)}
+}
+
+// Put stores a copy of the file
+func (s *MemoryStore) Put(ctx context.Context, key string, contentType string, body io.Reader, size int64) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(body, data); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key] = data
+	return nil
+}
+
+// Get returns the file stored under key
+func (s *MemoryStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.files[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Delete removes the file stored under key
+func (s *MemoryStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, key)
+	return nil
+}
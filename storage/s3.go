@@ -0,0 +1,244 @@
+package storage
+
+import (
+	"bytes"         // To send the uploaded bytes as the request body
+	"context"       // To cancel requests together with the HTTP request that caused them
+	"crypto/hmac"   // AWS Signature Version 4 is a chain of HMAC-SHA256
+	"crypto/sha256" // To hash the payload and the canonical request
+	"encoding/hex"  // Hashes and signatures are sent as hex strings
+	"fmt"           // To build the error messages of failed requests
+	"io"            // To read the uploaded bytes and the responses
+	"net/http"      // To talk to the S3 API
+	"net/url"       // To build and escape the object URLs
+	"sort"          // Signed headers must be sorted
+	"strings"       // To build the canonical request
+	"time"          // Signatures are tied to the time of the request
+)
+
+// S3Config holds the settings of an S3-compatible bucket
+// Endpoint is the base URL of the API, e.g. "https://s3.eu-west-1.amazonaws.com", or "http://localhost:9000"
+// for a local stand-in such as MinIO. Objects are addressed path-style (endpoint/bucket/key),
+// which AWS and every S3-compatible server understand.
+type S3Config struct {
+	Endpoint        string
+	Region          string // e.g. "us-east-1"; MinIO accepts any region
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Client          *http.Client // Optional, http.DefaultClient when nil
+}
+
+// S3Store is a Store that keeps files in an S3-compatible bucket
+// Requests are signed with AWS Signature Version 4, so no SDK is needed
+type S3Store struct {
+	config S3Config
+	client *http.Client
+}
+
+// NewS3Store creates an S3Store for the bucket described by config
+func NewS3Store(config S3Config) *S3Store {
+	client := config.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	return &S3Store{config: config, client: client}
+}
+
+// emptyPayloadHash is the SHA-256 of an empty body, used by GET and DELETE requests
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Put uploads the file with a single PUT request
+// The body is read into memory to compute the payload hash the signature needs; uploads are small enough for that
+func (s *S3Store) Put(ctx context.Context, key string, contentType string, body io.Reader, size int64) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(body, data); err != nil {
+		return err
+	}
+
+	req, err := s.newRequest(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := s.do(req, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return s.responseError(resp)
+	}
+	return nil
+}
+
+// Get downloads the file stored under key
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	req, err := s.newRequest(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrNotFound
+	}
+	defer resp.Body.Close()
+	return nil, s.responseError(resp)
+}
+
+// Delete removes the file stored under key; S3 answers 204 whether or not it existed
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	req, err := s.newRequest(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s.responseError(resp)
+	}
+	return nil
+}
+
+// newRequest builds the request for an object of the bucket
+func (s *S3Store) newRequest(ctx context.Context, method string, key string, body []byte) (*http.Request, error) {
+	objectURL := s.config.Endpoint + "/" + escapePath(s.config.Bucket+"/"+key)
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	return http.NewRequestWithContext(ctx, method, objectURL, reader)
+}
+
+// do signs the request and sends it
+func (s *S3Store) do(req *http.Request, body []byte) (*http.Response, error) {
+	payloadHash := emptyPayloadHash
+	if body != nil {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	signV4(req, payloadHash, s.config.Region, s.config.AccessKeyID, s.config.SecretAccessKey, time.Now())
+	return s.client.Do(req)
+}
+
+// responseError turns an unexpected response into an error that includes the beginning of the body,
+// where S3 explains what went wrong (e.g. "SignatureDoesNotMatch")
+func (s *S3Store) responseError(resp *http.Response) error {
+	message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("storage: %s %s: %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status, strings.TrimSpace(string(message)))
+}
+
+// signV4 adds the AWS Signature Version 4 headers to a request
+// The host, the Content-Type, the Range and every x-amz-* header are signed.
+// See https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func signV4(req *http.Request, payloadHash string, region string, accessKeyID string, secretAccessKey string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// Canonical headers: lower-case names, sorted, with trimmed values
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "range" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hashHex(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKeyID+"/"+scope+
+		", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalQuery encodes the query string the way Signature Version 4 expects: sorted, with %20 for spaces
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	parts := []string{}
+	for _, key := range keys {
+		values := query[key]
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, escape(key)+"="+escape(value))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath escapes every segment of an object path, keeping the slashes
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escape percent-encodes everything but the unreserved characters, as Signature Version 4 requires
+func escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// hashHex returns the hex SHA-256 of a string
+func hashHex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with the given key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"context" // Every call receives the request context, so a slow backend can be abandoned
+	"errors"  // To define the errors returned by the stores
+	"io"      // Files are read and written as streams
+	"strings" // To check the keys
+)
+
+// ErrNotFound is returned by Get when no file is stored under the key
+var ErrNotFound = errors.New("storage: file not found")
+
+// ErrInvalidKey is returned when a key could escape the storage area (e.g. "../secret")
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Store is the interface every file storage backend must implement
+// Controllers only talk to this interface, so uploads can live on the local disk, in S3 or in memory
+// without touching the code that accepts and serves them. Keys are relative paths such as "media/ab12.png".
+type Store interface {
+	// Put stores size bytes read from body under key, replacing any file already there
+	Put(ctx context.Context, key string, contentType string, body io.Reader, size int64) error
+	// Get opens the file stored under key; the caller must close it. It returns ErrNotFound when there is none.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the file stored under key; deleting a missing file is not an error
+	Delete(ctx context.Context, key string) error
+}
+
+// checkKey makes sure a key is a clean relative path
+// Keys are generated by the application, so this only guards against mistakes
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
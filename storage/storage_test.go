@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// testRoundTrip stores, reads, replaces and deletes a file through a store
+func testRoundTrip(t *testing.T, store Store) {
+	ctx := context.Background()
+	key := "media/ab 12.png" // The space checks that keys are escaped consistently
+
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get before Put: %v, want ErrNotFound", err)
+	}
+
+	for _, content := range []string{"first version", "second"} {
+		if err := store.Put(ctx, key, "image/png", strings.NewReader(content), int64(len(content))); err != nil {
+			t.Fatalf("Put: %v", err)
+		}
+		file, err := store.Get(ctx, key)
+		if err != nil {
+			t.Fatalf("Get: %v", err)
+		}
+		data, err := io.ReadAll(file)
+		file.Close()
+		if err != nil {
+			t.Fatalf("reading the file: %v", err)
+		}
+		if string(data) != content {
+			t.Fatalf("Get = %q, want %q", data, content)
+		}
+	}
+
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get after Delete: %v, want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete of a missing file: %v", err)
+	}
+
+	for _, invalid := range []string{"", "/media/a.png", "media/../a.png", "media//a.png", `media\a.png`} {
+		if err := store.Put(ctx, invalid, "image/png", strings.NewReader("x"), 1); !errors.Is(err, ErrInvalidKey) {
+			t.Fatalf("Put(%q): %v, want ErrInvalidKey", invalid, err)
+		}
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testRoundTrip(t, NewMemoryStore())
+}
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testRoundTrip(t, store)
+}
@@ -0,0 +1,125 @@
+package timeline
+
+import (
+	"GO-X/models" // Import the models package for the timeline entry types
+	"sort"        // To keep the entries in timeline order
+	"sync"        // To protect the timelines from concurrent access
+)
+
+// MemoryStore is a Store that keeps the timelines in memory
+// It is meant for tests and single-instance setups; timelines are lost on restart and can be rebuilt
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	timelines  map[int][]models.TimelineEntry
+}
+
+// NewMemoryStore creates an empty MemoryStore
+// Each timeline keeps at most maxEntries entries; the oldest ones are dropped first
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	return &MemoryStore{maxEntries: maxEntries, timelines: make(map[int][]models.TimelineEntry)}
+}
+
+// Push adds the entry to every given timeline
+func (s *MemoryStore) Push(entry models.TimelineEntry, userIDs ...int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, userID := range userIDs {
+		entries := s.timelines[userID]
+
+		// Find where the entry goes, newest first
+		i := sort.Search(len(entries), func(i int) bool {
+			return entries[i].Before(entry.TimelinePosition)
+		})
+		if i > 0 && entries[i-1].TimelinePosition == entry.TimelinePosition {
+			continue // Already there
+		}
+
+		entries = append(entries, models.TimelineEntry{})
+		copy(entries[i+1:], entries[i:])
+		entries[i] = entry
+		if len(entries) > s.maxEntries {
+			entries = entries[:s.maxEntries]
+		}
+		s.timelines[userID] = entries
+	}
+	return nil
+}
+
+// Range returns a page of a timeline
+func (s *MemoryStore) Range(userID int, after *models.TimelinePosition, limit int) ([]models.TimelineEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.timelines[userID]
+	start := 0
+	if after != nil {
+		start = sort.Search(len(entries), func(i int) bool {
+			return entries[i].Before(*after)
+		})
+	}
+	end := start + limit
+	if end > len(entries) {
+		end = len(entries)
+	}
+
+	page := make([]models.TimelineEntry, end-start)
+	copy(page, entries[start:end])
+	return page, nil
+}
+
+// RemoveTweet removes the tweet and its retweets from every timeline
+func (s *MemoryStore) RemoveTweet(tweetID int) error {
+	return s.removeWhere(func(userID int, entry models.TimelineEntry) bool {
+		return entry.TweetID == tweetID
+	})
+}
+
+// RemoveRetweet removes one retweet from every timeline
+func (s *MemoryStore) RemoveRetweet(retweeterID int, tweetID int) error {
+	return s.removeWhere(func(userID int, entry models.TimelineEntry) bool {
+		return entry.Kind == models.TimelineKindRetweet && entry.SourceID == retweeterID && entry.TweetID == tweetID
+	})
+}
+
+// RemoveSource removes the entries of one account from one timeline
+func (s *MemoryStore) RemoveSource(userID int, sourceID int) error {
+	return s.removeWhere(func(owner int, entry models.TimelineEntry) bool {
+		return owner == userID && entry.SourceID == sourceID
+	})
+}
+
+// Replace swaps a whole timeline
+func (s *MemoryStore) Replace(userID int, entries []models.TimelineEntry) error {
+	sorted := make([]models.TimelineEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[j].Before(sorted[i].TimelinePosition)
+	})
+	if len(sorted) > s.maxEntries {
+		sorted = sorted[:s.maxEntries]
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.timelines[userID] = sorted
+	return nil
+}
+
+// removeWhere drops every entry matching the predicate
+func (s *MemoryStore) removeWhere(match func(userID int, entry models.TimelineEntry) bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for userID, entries := range s.timelines {
+		kept := entries[:0]
+		for _, entry := range entries {
+			if !match(userID, entry) {
+				kept = append(kept, entry)
+			}
+		}
+		s.timelines[userID] = kept
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package timeline
+
+import (
+	"GO-X/models"  // Import the models package to read tweets, retweets and followers
+	"database/sql" // Import the database/sql package to interact with SQL databases
+)
+
+// Service materializes home timelines with fan-out-on-write
+// When an account posts, the entry is pushed into the timeline of every follower. Accounts with more
+// than CelebrityThreshold followers are not fanned out: their activity is pulled and merged at read time,
+// so one post doesn't turn into millions of writes.
+type Service struct {
+	db                 *sql.DB
+	store              Store
+	celebrityThreshold int
+	// BackfillSize is the number of recent entries copied into a timeline after a follow
+	BackfillSize int
+	// RebuildSize is the number of entries written when a timeline is rebuilt
+	RebuildSize int
+}
+
+// NewService creates a timeline service
+// Accounts with more than celebrityThreshold followers are merged at read time instead of fanned out
+func NewService(db *sql.DB, store Store, celebrityThreshold int) *Service {
+	return &Service{
+		db:                 db,
+		store:              store,
+		celebrityThreshold: celebrityThreshold,
+		BackfillSize:       50,
+		RebuildSize:        800,
+	}
+}
+
+// OnTweet fans a new tweet out to its author and, unless the author is a celebrity, to their followers
+func (s *Service) OnTweet(tweet *models.Tweet) error {
+	entry := models.TimelineEntry{
+		TimelinePosition: models.TimelinePosition{At: tweet.CreatedAt.Unix(), Kind: models.TimelineKindTweet, ID: tweet.ID},
+		TweetID:          tweet.ID,
+		SourceID:         tweet.UserID,
+	}
+	return s.fanOut(entry, true)
+}
+
+// OnTweetDeleted removes a deleted tweet (and its retweets) from every timeline
+func (s *Service) OnTweetDeleted(tweetID int) error {
+	return s.store.RemoveTweet(tweetID)
+}
+
+// OnRetweet fans a new retweet out to the followers of the retweeter
+func (s *Service) OnRetweet(retweeterID int, tweetID int) error {
+	retweet, err := models.GetRetweet(s.db, retweeterID, tweetID)
+	if err != nil || retweet == nil {
+		return err
+	}
+	entry := models.TimelineEntry{
+		TimelinePosition: models.TimelinePosition{At: retweet.CreatedAt.Unix(), Kind: models.TimelineKindRetweet, ID: retweet.ID},
+		TweetID:          tweetID,
+		SourceID:         retweeterID,
+	}
+	// Users don't see their own retweets in their home timeline, so the author isn't included
+	return s.fanOut(entry, false)
+}
+
+// OnUnretweet removes a retweet from every timeline
+func (s *Service) OnUnretweet(retweeterID int, tweetID int) error {
+	return s.store.RemoveRetweet(retweeterID, tweetID)
+}
+
+// OnFollow copies the recent activity of the followed account into the follower's timeline
+// Celebrities are skipped since their activity is pulled at read time anyway
+func (s *Service) OnFollow(followerID int, followingID int) error {
+	celebrity, err := s.isCelebrity(followingID)
+	if err != nil || celebrity {
+		return err
+	}
+	entries, err := models.GetSourceTimelineEntries(s.db, []int{followingID}, nil, s.BackfillSize)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := s.store.Push(entry, followerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnUnfollow removes the entries of the unfollowed account from the follower's timeline
+// When the unfollow brings a celebrity back down to the threshold, its activity is no longer pulled
+// at read time, so its recent entries are pushed into the timelines of its remaining followers.
+func (s *Service) OnUnfollow(followerID int, followingID int) error {
+	if err := s.store.RemoveSource(followerID, followingID); err != nil {
+		return err
+	}
+	count, err := models.CountFollowers(s.db, followingID)
+	if err != nil || count != s.celebrityThreshold {
+		return err
+	}
+	return s.backfillFollowers(followingID)
+}
+
+// Home returns a page of the home timeline of a user
+// Materialized entries are merged with the activity of the celebrities the user follows.
+func (s *Service) Home(userID int, after *models.TimelinePosition, limit int) ([]models.TimelineEntry, error) {
+	materialized, err := s.store.Range(userID, after, limit)
+	if err != nil {
+		return nil, err
+	}
+	if after == nil && len(materialized) == 0 {
+		// An empty timeline was most likely never materialized (e.g. the account predates fan-out):
+		// build it now instead of waiting for someone to run cmd/rebuild-timeline
+		if _, err := s.Rebuild(userID); err != nil {
+			return nil, err
+		}
+		if materialized, err = s.store.Range(userID, nil, limit); err != nil {
+			return nil, err
+		}
+	}
+
+	celebrities, err := models.GetFollowedAccountsAbove(s.db, userID, s.celebrityThreshold)
+	if err != nil {
+		return nil, err
+	}
+	pulled, err := models.GetSourceTimelineEntries(s.db, celebrities, after, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeEntries(limit, materialized, pulled), nil
+}
+
+// Rebuild recomputes the timeline of a user from the tweets, retweets and followers tables
+// It repairs timelines that missed writes, or that were created before fan-out was enabled
+func (s *Service) Rebuild(userID int) (int, error) {
+	entries, err := models.GetHomeTimelineEntries(s.db, userID, nil, s.RebuildSize)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.store.Replace(userID, entries); err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// fanOut pushes an entry to the followers of its source (and to the source itself when includeSource is set)
+func (s *Service) fanOut(entry models.TimelineEntry, includeSource bool) error {
+	var userIDs []int
+	if includeSource {
+		userIDs = append(userIDs, entry.SourceID)
+	}
+
+	celebrity, err := s.isCelebrity(entry.SourceID)
+	if err != nil {
+		return err
+	}
+	if !celebrity {
+		followers, err := models.GetFollowerIDs(s.db, entry.SourceID)
+		if err != nil {
+			return err
+		}
+		userIDs = append(userIDs, followers...)
+	}
+
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return s.store.Push(entry, userIDs...)
+}
+
+// backfillFollowers pushes the recent activity of an account into the timeline of every follower
+// Entries the timelines already hold are skipped by the store.
+func (s *Service) backfillFollowers(sourceID int) error {
+	followers, err := models.GetFollowerIDs(s.db, sourceID)
+	if err != nil || len(followers) == 0 {
+		return err
+	}
+	entries, err := models.GetSourceTimelineEntries(s.db, []int{sourceID}, nil, s.BackfillSize)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := s.store.Push(entry, followers...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isCelebrity reports whether the account has more followers than the fan-out threshold
+func (s *Service) isCelebrity(userID int) (bool, error) {
+	count, err := models.CountFollowers(s.db, userID)
+	if err != nil {
+		return false, err
+	}
+	return count > s.celebrityThreshold, nil
+}
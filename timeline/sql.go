@@ -0,0 +1,174 @@
+package timeline
+
+import (
+	"GO-X/models"  // Import the models package for the timeline entry types
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"strings"      // To build multi-row INSERT statements
+	"time"         // To convert activity times
+)
+
+// pushBatchSize is the number of rows written by a single INSERT during a fan-out
+const pushBatchSize = 500
+
+// SQLStore is a Store backed by the "timeline_entries" table
+// Timelines are shared by every server instance and survive restarts
+type SQLStore struct {
+	db         *sql.DB
+	maxEntries int
+}
+
+// NewSQLStore creates a SQLStore using the given database connection
+// Each timeline keeps at most maxEntries entries; the oldest ones are deleted first
+func NewSQLStore(db *sql.DB, maxEntries int) *SQLStore {
+	return &SQLStore{db: db, maxEntries: maxEntries}
+}
+
+// Push inserts the entry in every given timeline, in batches, and trims those timelines
+func (s *SQLStore) Push(entry models.TimelineEntry, userIDs ...int) error {
+	for start := 0; start < len(userIDs); start += pushBatchSize {
+		end := start + pushBatchSize
+		if end > len(userIDs) {
+			end = len(userIDs)
+		}
+		if err := insertEntries(s.db, userIDs[start:end], []models.TimelineEntry{entry}); err != nil {
+			return err
+		}
+		if err := trimTimelines(s.db, userIDs[start:end], s.maxEntries); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Range returns a page of a timeline
+// The first page and the following ones use separate queries so each can use the (user_id, activity_at) index.
+func (s *SQLStore) Range(userID int, after *models.TimelinePosition, limit int) ([]models.TimelineEntry, error) {
+	var rows *sql.Rows
+	var err error
+	if after == nil {
+		rows, err = s.db.Query(`SELECT activity_at, kind, item_id, tweet_id, source_id
+			FROM timeline_entries
+			WHERE user_id = ?
+			ORDER BY activity_at DESC, kind DESC, item_id DESC
+			LIMIT ?`, userID, limit)
+	} else {
+		rows, err = s.db.Query(`SELECT activity_at, kind, item_id, tweet_id, source_id
+			FROM timeline_entries
+			WHERE user_id = ? AND (activity_at, kind, item_id) < (?, ?, ?)
+			ORDER BY activity_at DESC, kind DESC, item_id DESC
+			LIMIT ?`, userID, time.Unix(after.At, 0), after.Kind, after.ID, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.TimelineEntry{}
+	for rows.Next() {
+		var entry models.TimelineEntry
+		var activityAt time.Time
+		if err := rows.Scan(&activityAt, &entry.Kind, &entry.ID, &entry.TweetID, &entry.SourceID); err != nil {
+			return nil, err
+		}
+		entry.At = activityAt.Unix()
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// RemoveTweet removes the tweet and its retweets from every timeline
+func (s *SQLStore) RemoveTweet(tweetID int) error {
+	_, err := s.db.Exec("DELETE FROM timeline_entries WHERE tweet_id = ?", tweetID)
+	return err
+}
+
+// RemoveRetweet removes one retweet from every timeline
+func (s *SQLStore) RemoveRetweet(retweeterID int, tweetID int) error {
+	_, err := s.db.Exec("DELETE FROM timeline_entries WHERE tweet_id = ? AND kind = ? AND source_id = ?",
+		tweetID, models.TimelineKindRetweet, retweeterID)
+	return err
+}
+
+// RemoveSource removes the entries of one account from one timeline
+func (s *SQLStore) RemoveSource(userID int, sourceID int) error {
+	_, err := s.db.Exec("DELETE FROM timeline_entries WHERE user_id = ? AND source_id = ?", userID, sourceID)
+	return err
+}
+
+// Replace swaps a whole timeline in one transaction
+func (s *SQLStore) Replace(userID int, entries []models.TimelineEntry) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // Does nothing if the transaction was committed
+
+	if _, err := tx.Exec("DELETE FROM timeline_entries WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for start := 0; start < len(entries); start += pushBatchSize {
+		end := start + pushBatchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		if err := insertEntries(tx, []int{userID}, entries[start:end]); err != nil {
+			return err
+		}
+	}
+	if err := trimTimelines(tx, []int{userID}, s.maxEntries); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertEntries writes every entry in every timeline with a single INSERT IGNORE
+func insertEntries(db execer, userIDs []int, entries []models.TimelineEntry) error {
+	if len(userIDs) == 0 || len(entries) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(userIDs)*len(entries))
+	args := make([]interface{}, 0, len(userIDs)*len(entries)*6)
+	for _, userID := range userIDs {
+		for _, entry := range entries {
+			values = append(values, "(?, ?, ?, ?, ?, ?)")
+			args = append(args, userID, entry.Kind, entry.ID, entry.TweetID, entry.SourceID, time.Unix(entry.At, 0))
+		}
+	}
+
+	_, err := db.Exec("INSERT IGNORE INTO timeline_entries (user_id, kind, item_id, tweet_id, source_id, activity_at) VALUES "+
+		strings.Join(values, ", "), args...)
+	return err
+}
+
+// trimTimelines deletes the entries of the given timelines beyond the newest maxEntries
+func trimTimelines(db execer, userIDs []int, maxEntries int) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, len(userIDs)+1)
+	for _, userID := range userIDs {
+		args = append(args, userID)
+	}
+	args = append(args, maxEntries)
+
+	// The ranked rows are materialized before the delete, so the table can be read and deleted from at once
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(userIDs)), ", ")
+	_, err := db.Exec(`DELETE te FROM timeline_entries te
+		JOIN (
+			SELECT user_id, kind, item_id FROM (
+				SELECT user_id, kind, item_id,
+					ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY activity_at DESC, kind DESC, item_id DESC) AS position
+				FROM timeline_entries
+				WHERE user_id IN (`+placeholders+`)
+			) ranked
+			WHERE position > ?
+		) old ON old.user_id = te.user_id AND old.kind = te.kind AND old.item_id = te.item_id`, args...)
+	return err
+}
@@ -0,0 +1,57 @@
+package timeline
+
+import (
+	"GO-X/models" // Import the models package for the timeline entry types
+)
+
+// Store holds the materialized home timelines, one list of entries per user
+// Entries are kept in timeline order (newest first) and are unique per (kind, item ID) in a timeline.
+type Store interface {
+	// Push adds one entry to the timelines of several users; pushing the same entry twice is a no-op
+	Push(entry models.TimelineEntry, userIDs ...int) error
+	// Range returns up to limit entries of a timeline, starting right after the given position (nil for the newest)
+	Range(userID int, after *models.TimelinePosition, limit int) ([]models.TimelineEntry, error)
+	// RemoveTweet removes every entry pointing at the tweet, in every timeline
+	RemoveTweet(tweetID int) error
+	// RemoveRetweet removes the retweet of tweetID by retweeterID, in every timeline
+	RemoveRetweet(retweeterID int, tweetID int) error
+	// RemoveSource removes every entry that came from sourceID from one timeline (after an unfollow)
+	RemoveSource(userID int, sourceID int) error
+	// Replace swaps the whole timeline of a user for the given entries (used when rebuilding)
+	Replace(userID int, entries []models.TimelineEntry) error
+}
+
+// mergeEntries merges several lists sorted newest first into one list of at most limit entries
+// Entries present in more than one list are only kept once
+func mergeEntries(limit int, lists ...[]models.TimelineEntry) []models.TimelineEntry {
+	type key struct{ kind, id int }
+	seen := make(map[key]bool)
+	merged := []models.TimelineEntry{}
+	indexes := make([]int, len(lists))
+
+	for len(merged) < limit {
+		// Pick the newest head among the lists
+		best := -1
+		for i, list := range lists {
+			if indexes[i] >= len(list) {
+				continue
+			}
+			if best == -1 || lists[best][indexes[best]].Before(list[indexes[i]].TimelinePosition) {
+				best = i
+			}
+		}
+		if best == -1 {
+			break // Every list is exhausted
+		}
+
+		entry := lists[best][indexes[best]]
+		indexes[best]++
+		k := key{entry.Kind, entry.ID}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		merged = append(merged, entry)
+	}
+	return merged
+}
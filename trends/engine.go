@@ -0,0 +1,235 @@
+// Package trends finds the hashtags and words people suddenly tweet about
+// An Engine counts topics in time buckets and compares the last Window with the Baseline before it,
+// so a topic trends because it is used much more than usual, not because it is always popular.
+// The Engine knows nothing about the database and takes its clock from its Config, so it can be fed
+// synthetic tweets at made-up times; a Tracker feeds it the tweets of the database.
+package trends
+
+import (
+	"GO-X/entities" // Import the entities package to find the hashtags of a tweet
+	"math"          // For the square root of the score
+	"sort"          // To rank the topics
+	"sync"          // The engine is fed and ranked from different goroutines
+	"time"          // Counts are kept per time bucket
+)
+
+// Kinds of trends
+const (
+	KindHashtag = "hashtag" // A #hashtag, counted by its normalized tag
+	KindTerm    = "term"    // A word of the content (see Terms)
+)
+
+// Config sets the windows and thresholds of an Engine; zero values take the defaults
+type Config struct {
+	Window   time.Duration    // Period whose activity is ranked, 1 hour by default
+	Baseline time.Duration    // Period before Window that sets the usual activity, 24 hours by default
+	Bucket   time.Duration    // Granularity of the counts, 5 minutes by default; the windows slide one bucket at a time
+	MinCount int              // Fewest tweets in Window for a topic to trend, 5 by default
+	Size     int              // Number of trends ranked per kind, 10 by default
+	Now      func() time.Time // The clock, time.Now by default
+}
+
+// Post is a tweet as far as trends are concerned
+type Post struct {
+	Text string
+	At   time.Time // When it was posted
+}
+
+// Trend is a topic whose use went up compared with the baseline
+type Trend struct {
+	Kind     string  `json:"kind"`     // KindHashtag or KindTerm
+	Name     string  `json:"name"`     // The tag (without "#") or the word
+	Count    int     `json:"count"`    // Tweets using it during the window
+	Expected float64 `json:"expected"` // Tweets expected during a window of that length, from the baseline
+	Velocity float64 `json:"velocity"` // How many times the usual rate it is used at: (Count + 1) / (Expected + 1)
+	Score    float64 `json:"score"`    // What trends are ranked by, see Engine.Rank
+}
+
+// Rankings are the trends computed at one point in time
+type Rankings struct {
+	Hashtags   []Trend   `json:"hashtags"`
+	Terms      []Trend   `json:"terms"`
+	ComputedAt time.Time `json:"computed_at"`
+}
+
+// bucket holds the number of posts using each topic during one Bucket of time
+type bucket struct {
+	hashtags map[string]int
+	terms    map[string]int
+}
+
+// Engine counts the topics of posts and ranks the trending ones
+// It is safe for concurrent use.
+type Engine struct {
+	config Config
+
+	mu      sync.Mutex
+	buckets map[int64]*bucket // By bucket number, see bucketOf
+	since   int64             // The earliest bucket the engine has seen: when it started, or its oldest post
+}
+
+// NewEngine creates an engine that starts counting now
+// Posts made before now (up to the horizon) can still be added, e.g. to fill the baseline after a restart.
+func NewEngine(config Config) *Engine {
+	if config.Window <= 0 {
+		config.Window = time.Hour
+	}
+	if config.Baseline <= 0 {
+		config.Baseline = 24 * time.Hour
+	}
+	if config.Bucket <= 0 {
+		config.Bucket = 5 * time.Minute
+	}
+	if config.MinCount <= 0 {
+		config.MinCount = 5
+	}
+	if config.Size <= 0 {
+		config.Size = 10
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	engine := &Engine{config: config, buckets: map[int64]*bucket{}}
+	engine.since = engine.bucketOf(config.Now())
+	return engine
+}
+
+// Horizon returns the time before which posts no longer count
+// A feed starting from scratch only needs the posts made since then.
+func (e *Engine) Horizon() time.Time {
+	return e.config.Now().Add(-e.config.Window - e.config.Baseline)
+}
+
+// Add counts a post; posts older than the horizon are ignored
+// Each hashtag and term is counted once per post, however many times it appears in it.
+func (e *Engine) Add(post Post) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.bucketOf(e.config.Now())
+	number := e.bucketOf(post.At)
+	if number <= now-e.windowBuckets()-e.baselineBuckets() {
+		return
+	}
+	if number > now {
+		number = now // Count posts dated in the future (clock skew) as current
+	}
+	if number < e.since {
+		e.since = number
+	}
+
+	b := e.buckets[number]
+	if b == nil {
+		b = &bucket{hashtags: map[string]int{}, terms: map[string]int{}}
+		e.buckets[number] = b
+	}
+	for _, tag := range entities.HashtagTags(entities.ExtractHashtags(post.Text)) {
+		b.hashtags[tag]++
+	}
+	for _, term := range Terms(post.Text) {
+		b.terms[term]++
+	}
+}
+
+// Rank computes the trends at the current time of the clock, and forgets the buckets that fell out of the baseline
+// For each topic, the count of the window is compared with the count expected from the baseline, scaled to the
+// length of the window. Topics are ranked by Score = (Count - Expected) / sqrt(Expected + 1), which is roughly how
+// many standard deviations the count is above what is usual: a topic going from 2 to 20 tweets an hour outranks
+// one going from 500 to 600. Topics used in fewer than MinCount tweets, or not more than expected, don't trend.
+func (e *Engine) Rank() Rankings {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := e.config.Now()
+	current := e.bucketOf(now)
+	windowStart := current - e.windowBuckets() // Window buckets are (windowStart, current]
+	baselineStart := windowStart - e.baselineBuckets()
+
+	// Sum the counts of the window and of the baseline
+	windowHashtags, windowTerms := map[string]int{}, map[string]int{}
+	baselineHashtags, baselineTerms := map[string]int{}, map[string]int{}
+	for number, b := range e.buckets {
+		switch {
+		case number <= baselineStart:
+			delete(e.buckets, number)
+		case number <= windowStart:
+			addCounts(baselineHashtags, b.hashtags)
+			addCounts(baselineTerms, b.terms)
+		default:
+			addCounts(windowHashtags, b.hashtags)
+			addCounts(windowTerms, b.terms)
+		}
+	}
+
+	// Only the part of the baseline the engine has seen counts: a new engine with no older posts has a shorter history
+	known := windowStart - max(baselineStart, e.since)
+	scale := 0.0 // With no history, everything is new
+	if known > 0 {
+		scale = float64(e.windowBuckets()) / float64(known)
+	}
+
+	return Rankings{
+		Hashtags:   e.rank(KindHashtag, windowHashtags, baselineHashtags, scale),
+		Terms:      e.rank(KindTerm, windowTerms, baselineTerms, scale),
+		ComputedAt: now,
+	}
+}
+
+// rank scores the topics of one kind and keeps the best Size of them
+func (e *Engine) rank(kind string, window map[string]int, baseline map[string]int, scale float64) []Trend {
+	trends := []Trend{}
+	for name, count := range window {
+		expected := float64(baseline[name]) * scale
+		if count < e.config.MinCount || float64(count) <= expected {
+			continue
+		}
+		trends = append(trends, Trend{
+			Kind:     kind,
+			Name:     name,
+			Count:    count,
+			Expected: math.Round(expected*100) / 100,
+			Velocity: math.Round((float64(count)+1)/(expected+1)*100) / 100,
+			Score:    (float64(count) - expected) / math.Sqrt(expected+1),
+		})
+	}
+
+	// Best score first; ties go to the most used topic, then to alphabetical order so rankings are stable
+	sort.Slice(trends, func(i, j int) bool {
+		if trends[i].Score != trends[j].Score {
+			return trends[i].Score > trends[j].Score
+		}
+		if trends[i].Count != trends[j].Count {
+			return trends[i].Count > trends[j].Count
+		}
+		return trends[i].Name < trends[j].Name
+	})
+	if len(trends) > e.config.Size {
+		trends = trends[:e.config.Size]
+	}
+	for i := range trends {
+		trends[i].Score = math.Round(trends[i].Score*100) / 100
+	}
+	return trends
+}
+
+// bucketOf returns the number of the bucket a time falls in
+func (e *Engine) bucketOf(t time.Time) int64 {
+	return t.UnixNano() / int64(e.config.Bucket)
+}
+
+// windowBuckets returns the number of buckets in Window (at least one)
+func (e *Engine) windowBuckets() int64 {
+	return max(int64(e.config.Window/e.config.Bucket), 1)
+}
+
+// baselineBuckets returns the number of buckets in Baseline (at least one)
+func (e *Engine) baselineBuckets() int64 {
+	return max(int64(e.config.Baseline/e.config.Bucket), 1)
+}
+
+// addCounts adds the counts of from to to
+func addCounts(to map[string]int, from map[string]int) {
+	for name, count := range from {
+		to[name] += count
+	}
+}
@@ -0,0 +1,161 @@
+package trends
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// testClock is a clock the tests move by hand
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+// newTestEngine creates an engine with a one hour window and a one hour baseline, in 5 minute buckets,
+// started two hours ago so its whole baseline is known. It returns the engine and its clock, set to "now".
+func newTestEngine(config Config) (*Engine, *testClock) {
+	clock := &testClock{now: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)}
+	config.Window = time.Hour
+	config.Baseline = time.Hour
+	config.Bucket = 5 * time.Minute
+	config.Now = clock.Now
+	engine := NewEngine(config)
+	clock.now = clock.now.Add(2 * time.Hour)
+	return engine, clock
+}
+
+// post adds count posts with the given text, posted ago before the clock's time
+func post(engine *Engine, clock *testClock, text string, count int, ago time.Duration) {
+	for i := 0; i < count; i++ {
+		engine.Add(Post{Text: text, At: clock.now.Add(-ago)})
+	}
+}
+
+// names returns the names of trends, in order
+func names(trends []Trend) []string {
+	result := make([]string, len(trends))
+	for i, trend := range trends {
+		result[i] = trend.Name
+	}
+	return result
+}
+
+func TestRankComparesTheWindowWithTheBaseline(t *testing.T) {
+	engine, clock := newTestEngine(Config{})
+
+	// 90 minutes ago falls in the baseline, 10 minutes ago in the window
+	post(engine, clock, "#small", 2, 90*time.Minute)
+	post(engine, clock, "#small", 20, 10*time.Minute)
+	post(engine, clock, "#big", 500, 90*time.Minute)
+	post(engine, clock, "#big", 600, 10*time.Minute)
+	post(engine, clock, "#steady", 10, 90*time.Minute)
+	post(engine, clock, "#steady", 10, 10*time.Minute)
+
+	rankings := engine.Rank()
+	if got := strings.Join(names(rankings.Hashtags), ","); got != "small,big" {
+		t.Fatalf("hashtags = %s, want small,big: a jump from 2 to 20 outranks one from 500 to 600, "+
+			"and a topic used as much as usual doesn't trend", got)
+	}
+
+	small := rankings.Hashtags[0]
+	if small.Kind != KindHashtag || small.Count != 20 || small.Expected != 2 || small.Velocity != 7 || small.Score != 10.39 {
+		t.Fatalf("small = %+v, want count 20, expected 2, velocity 7 and score 10.39", small)
+	}
+	big := rankings.Hashtags[1]
+	if big.Count != 600 || big.Expected != 500 || big.Score != 4.47 {
+		t.Fatalf("big = %+v, want count 600, expected 500 and score 4.47", big)
+	}
+	if !rankings.ComputedAt.Equal(clock.now) {
+		t.Fatalf("ComputedAt = %v, want the clock's time %v", rankings.ComputedAt, clock.now)
+	}
+}
+
+func TestRankMinCount(t *testing.T) {
+	engine, clock := newTestEngine(Config{MinCount: 5})
+
+	post(engine, clock, "#rare", 4, 10*time.Minute)
+	post(engine, clock, "#enough", 5, 10*time.Minute)
+
+	rankings := engine.Rank()
+	if got := strings.Join(names(rankings.Hashtags), ","); got != "enough" {
+		t.Fatalf("hashtags = %s, want enough: #rare is used in fewer than MinCount tweets", got)
+	}
+	if trend := rankings.Hashtags[0]; trend.Expected != 0 || trend.Score != 5 {
+		t.Fatalf("enough = %+v, want expected 0 and score 5 with no baseline", trend)
+	}
+}
+
+func TestRankForgetsExpiredBuckets(t *testing.T) {
+	engine, clock := newTestEngine(Config{})
+
+	post(engine, clock, "#old", 10, 90*time.Minute)
+	post(engine, clock, "#recent", 10, 10*time.Minute)
+	engine.Rank()
+	if len(engine.buckets) != 2 {
+		t.Fatalf("%d buckets, want 2", len(engine.buckets))
+	}
+
+	// An hour later #recent is in the baseline and #old fell out of it
+	clock.now = clock.now.Add(time.Hour)
+	if rankings := engine.Rank(); len(rankings.Hashtags) != 0 {
+		t.Fatalf("hashtags = %v, want none", names(rankings.Hashtags))
+	}
+	if len(engine.buckets) != 1 {
+		t.Fatalf("%d buckets, want 1 once the oldest fell out of the baseline", len(engine.buckets))
+	}
+
+	// Another hour later nothing is left
+	clock.now = clock.now.Add(time.Hour)
+	engine.Rank()
+	if len(engine.buckets) != 0 {
+		t.Fatalf("%d buckets, want none", len(engine.buckets))
+	}
+}
+
+func TestAddIgnoresPostsBeyondTheHorizon(t *testing.T) {
+	engine, clock := newTestEngine(Config{})
+
+	post(engine, clock, "#ancient", 10, 3*time.Hour)
+	if len(engine.buckets) != 0 {
+		t.Fatalf("%d buckets, want none for posts older than the horizon", len(engine.buckets))
+	}
+	if want := clock.now.Add(-2 * time.Hour); !engine.Horizon().Equal(want) {
+		t.Fatalf("Horizon = %v, want %v", engine.Horizon(), want)
+	}
+
+	// Posts dated in the future count as current
+	post(engine, clock, "#skewed", 5, -time.Hour)
+	if got := strings.Join(names(engine.Rank().Hashtags), ","); got != "skewed" {
+		t.Fatalf("hashtags = %s, want skewed", got)
+	}
+}
+
+func TestRankCountsEachTopicOncePerPost(t *testing.T) {
+	engine, clock := newTestEngine(Config{})
+
+	post(engine, clock, "#Go #go #GO gophers gophers Gophers", 5, 10*time.Minute)
+
+	rankings := engine.Rank()
+	if len(rankings.Hashtags) != 1 || rankings.Hashtags[0].Name != "go" || rankings.Hashtags[0].Count != 5 {
+		t.Fatalf("hashtags = %+v, want go used in 5 tweets", rankings.Hashtags)
+	}
+	if len(rankings.Terms) != 1 || rankings.Terms[0].Kind != KindTerm || rankings.Terms[0].Name != "gophers" || rankings.Terms[0].Count != 5 {
+		t.Fatalf("terms = %+v, want gophers used in 5 tweets", rankings.Terms)
+	}
+}
+
+func TestRankKeepsSizeTrends(t *testing.T) {
+	engine, clock := newTestEngine(Config{Size: 2})
+
+	post(engine, clock, "#first", 30, 10*time.Minute)
+	post(engine, clock, "#second", 20, 10*time.Minute)
+	post(engine, clock, "#third", 10, 10*time.Minute)
+
+	if got := strings.Join(names(engine.Rank().Hashtags), ","); got != "first,second" {
+		t.Fatalf("hashtags = %s, want first,second", got)
+	}
+}
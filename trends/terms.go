@@ -0,0 +1,85 @@
+package trends
+
+import (
+	"GO-X/entities" // Import the entities package to find the hashtags and mentions of a text
+	"strings"       // To split and lower-case words
+	"unicode"       // To find word boundaries in any script
+)
+
+const (
+	minTermLength = 3  // Shorter words are too common to mean anything ("ok", "lol")
+	maxTermLength = 30 // Longer "words" are usually links or text written without spaces
+)
+
+// stopWords are common English words that are never trends
+var stopWords = map[string]bool{}
+
+func init() {
+	for _, word := range strings.Fields(`about above after again against all also and any are aren because been before
+		being below between both but can cannot could did does doing don down during each few for from further get got had
+		has have having her here hers herself him himself his how into its itself just let more most much must myself new
+		nor not now off once one only other our ours ourselves out over own same she should some such than that the their
+		theirs them themselves then there these they this those through too under until very was way were what when where
+		which while who whom why will with would you your yours yourself yourselves today really like know think want need
+		going make good time`) {
+		stopWords[word] = true
+	}
+}
+
+// Terms returns the distinct words of a text that can be trends, lower-cased, in order of first appearance
+// Hashtags, mentions, links and e-mail addresses are left out (hashtags are counted on their own), as are stop words,
+// numbers, words with an apostrophe ("don't") and words shorter than 3 or longer than 30 characters.
+func Terms(text string) []string {
+	// Blank out the hashtags and mentions wherever they are, e.g. in "(#golang)" or "text,@bob"
+	runes := []rune(text)
+	for _, hashtag := range entities.ExtractHashtags(text) {
+		blank(runes, hashtag.Start, hashtag.End)
+	}
+	for _, mention := range entities.ExtractMentions(text) {
+		blank(runes, mention.Start, mention.End)
+	}
+
+	seen := map[string]bool{}
+	terms := []string{}
+	for _, field := range strings.Fields(string(runes)) {
+		// What is left of a "#" or "@" isn't a real word either: "##tag", "bob@example.com"
+		if strings.HasPrefix(field, "#") || strings.HasPrefix(field, "＃") || strings.ContainsAny(field, "@＠") ||
+			strings.Contains(field, "://") || strings.HasPrefix(strings.ToLower(field), "www.") {
+			continue
+		}
+		for _, word := range strings.FieldsFunc(field, func(r rune) bool { return !isWordRune(r) }) {
+			word = strings.ToLower(strings.Trim(word, "'’"))
+			if strings.ContainsAny(word, "'’") || !isTerm(word) || stopWords[word] || seen[word] {
+				continue
+			}
+			seen[word] = true
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// blank replaces runes[start:end] with spaces
+func blank(runes []rune, start int, end int) {
+	for i := start; i < end; i++ {
+		runes[i] = ' '
+	}
+}
+
+// isWordRune reports whether r can be part of a word; apostrophes are kept so contractions can be recognized
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsNumber(r) || r == '\'' || r == '’'
+}
+
+// isTerm checks the length of a word and that it has at least one letter
+func isTerm(word string) bool {
+	length := 0
+	hasLetter := false
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		}
+		length++
+	}
+	return hasLetter && length >= minTermLength && length <= maxTermLength
+}
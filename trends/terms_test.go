@@ -0,0 +1,74 @@
+package trends
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTerms(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "stop words and short words",
+			text: "The release of the compiler is really about speed",
+			want: []string{"release", "compiler", "speed"},
+		},
+		{
+			name: "hashtags and mentions",
+			text: "#golang meetup with @rob and ＠ken in ＃東京 tonight",
+			want: []string{"meetup", "tonight"},
+		},
+		{
+			name: "hashtags and mentions inside a field",
+			text: "(#golang) release,#golang notes,@rob ##gophers",
+			want: []string{"release", "notes"},
+		},
+		{
+			name: "e-mail addresses",
+			text: "write to bob@example.com or @bob@example.com today",
+			want: []string{"write"},
+		},
+		{
+			name: "links",
+			text: "slides at https://go.dev/talks and www.example.com/slides",
+			want: []string{"slides"},
+		},
+		{
+			name: "apostrophes",
+			text: "don't skip it’s 'quoted' gophers' talk",
+			want: []string{"skip", "quoted", "gophers", "talk"},
+		},
+		{
+			name: "numbers and punctuation",
+			text: "Go 1.23 ships! 2024, compiler-speed...",
+			want: []string{"ships", "compiler", "speed"},
+		},
+		{
+			name: "case and repeats",
+			text: "Gophers GOPHERS gophers",
+			want: []string{"gophers"},
+		},
+		{
+			name: "other scripts",
+			text: "東京タワー Café",
+			want: []string{"東京タワー", "café"},
+		},
+		{
+			name: "length",
+			text: strings.Repeat("a", 31) + " " + strings.Repeat("b", 30),
+			want: []string{strings.Repeat("b", 30)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Terms(test.text)
+			if strings.Join(got, "|") != strings.Join(test.want, "|") {
+				t.Fatalf("Terms(%q) = %q, want %q", test.text, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package trends
+
+import (
+	"GO-X/models"  // Import the models package to read new tweets
+	"database/sql" // Import the database/sql package for the database handle
+	"sync"         // Rankings are read by requests while the tracker refreshes them
+	"time"         // To read again the last minutes of tweets
+)
+
+const (
+	// trackerBatchSize is the number of tweets read from the database at a time
+	trackerBatchSize = 1000
+	// trackerOverlap is how far back before the newest tweet read each refresh starts again
+	// A tweet is dated when it is inserted but only visible once its transaction commits, so a tweet committed
+	// late may be older than tweets already read; it is still found as long as it is late by less than this.
+	trackerOverlap = 5 * time.Minute
+)
+
+// Tracker feeds an Engine the tweets of the database and keeps the latest rankings
+// Refresh is meant to be called periodically by a single goroutine; Rankings can be called from anywhere.
+// Every tweet counts: accounts that hide their profile details still tweet publicly.
+type Tracker struct {
+	db        *sql.DB
+	engine    *Engine
+	watermark time.Time         // When the newest tweet fed to the engine was posted
+	seen      map[int]time.Time // The tweets of the overlap already fed to the engine, with when they were posted
+
+	mu       sync.RWMutex
+	rankings Rankings
+}
+
+// NewTracker creates a tracker for the tweets of the database
+// The first Refresh reads the tweets of the whole horizon of the engine, so trends are right after a restart.
+func NewTracker(db *sql.DB, engine *Engine) *Tracker {
+	return &Tracker{
+		db:       db,
+		engine:   engine,
+		seen:     map[int]time.Time{},
+		rankings: Rankings{Hashtags: []Trend{}, Terms: []Trend{}},
+	}
+}
+
+// Refresh feeds the engine the tweets posted since the last refresh, then ranks the trends again
+// The last trackerOverlap of tweets is read again, skipping those already counted, to catch late commits.
+// Edits and deletions are not followed: a tweet counts as it was when it was first read.
+func (t *Tracker) Refresh() error {
+	since := t.engine.Horizon()
+	if overlap := t.watermark.Add(-trackerOverlap); overlap.After(since) {
+		since = overlap
+	}
+
+	afterAt, afterID := since, 0
+	for {
+		texts, err := models.GetTweetTextsSince(t.db, afterAt, afterID, trackerBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, text := range texts {
+			afterAt, afterID = text.CreatedAt, text.ID
+			if _, ok := t.seen[text.ID]; ok {
+				continue // Counted by an earlier refresh
+			}
+			t.seen[text.ID] = text.CreatedAt
+			t.engine.Add(Post{Text: text.Content, At: text.CreatedAt})
+			if text.CreatedAt.After(t.watermark) {
+				t.watermark = text.CreatedAt
+			}
+		}
+		if len(texts) < trackerBatchSize {
+			break
+		}
+	}
+
+	// Tweets older than the next overlap will never be read again
+	for id, at := range t.seen {
+		if at.Before(t.watermark.Add(-trackerOverlap)) {
+			delete(t.seen, id)
+		}
+	}
+
+	rankings := t.engine.Rank()
+	t.mu.Lock()
+	t.rankings = rankings
+	t.mu.Unlock()
+	return nil
+}
+
+// Rankings returns the trends computed by the last Refresh (empty lists before the first one)
+func (t *Tracker) Rankings() Rankings {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.rankings
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"encoding/base64" // To make cursors URL-safe and opaque
+	"encoding/json"   // To store the cursor fields
+)
+
+// EncodeCursor turns a pagination position into an opaque string that clients pass back as ?cursor=
+// Clients must not rely on what is inside, so the format can change without breaking them
+func EncodeCursor(position interface{}) string {
+	data, err := json.Marshal(position)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a cursor produced by EncodeCursor into position
+func DecodeCursor(cursor string, position interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, position)
+}
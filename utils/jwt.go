@@ -7,23 +7,68 @@ import (
 	"github.com/golang-jwt/jwt/v4" // Import the JWT library to create and verify tokens
 )
 
-// Define a secret key for signing JWT tokens (use a more secure key in production)
-var jwtSecretKey = []byte("your-secret-key")
+// AccessTokenTTL is how long an access token stays valid
+// It is kept short on purpose: clients use their refresh token to get a new one
+const AccessTokenTTL = 15 * time.Minute
+
+// MFAPendingTokenTTL is how long a user has to enter their two-factor code after their password
+const MFAPendingTokenTTL = 5 * time.Minute
+
+// Values of the "token_use" claim, which tells what a token may be used for
+const (
+	TokenUseAccess     = "access"      // A full access token, accepted by middleware.ProtectRoute
+	TokenUseMFAPending = "mfa_pending" // Proves the password was checked; only exchanged for an access token once the 2FA code is verified
+)
 
 // GenerateJWT generates a JWT token for the user
-// It takes the username as input and returns a signed JWT token as a string.
-func GenerateJWT(username string) (string, error) {
-	// Create a new JWT token using the HMAC SHA-256 signing method
-	token := jwt.New(jwt.SigningMethodHS256)
+// It takes the username and role as input and returns a signed JWT token as a string.
+// The role is embedded so routes can check permissions without a database query.
+func GenerateJWT(username string, role string) (string, error) {
+	return generateToken(username, role, TokenUseAccess, AccessTokenTTL)
+}
+
+// GenerateMFAPendingJWT generates the short-lived token issued after the password of a user with 2FA was checked
+// It can't be used to call the API, only to finish the login with a two-factor code
+func GenerateMFAPendingJWT(username string) (string, error) {
+	return generateToken(username, "", TokenUseMFAPending, MFAPendingTokenTTL)
+}
+
+// generateToken signs a token for the user with the given "role" and "token_use" claims and lifetime
+// An empty role leaves the claim out
+func generateToken(username string, role string, use string, ttl time.Duration) (string, error) {
+	if keySet == nil {
+		return "", errNoKeys
+	}
+
+	// Create a new JWT token using the signing method of the active key (HS256, RS256 or EdDSA)
+	// The "kid" header tells verifiers which key of the set was used
+	key := keySet.Active()
+	token := jwt.New(key.Method)
+	token.Header["kid"] = key.ID
+
+	// A unique token ID (jti) lets us revoke this specific token when the user logs out
+	jti, err := GenerateSecureToken(16)
+	if err != nil {
+		return "", err
+	}
 
 	// Set the claims (the data embedded inside the token)
 	// Claims can hold any data you want to store in the token.
+	now := time.Now()
 	claims := token.Claims.(jwt.MapClaims)
-	claims["username"] = username                         // Add the username to the claims
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix() // Set the expiration time to 24 hours from now
+	claims["username"] = username       // Add the username to the claims
+	claims["jti"] = jti                 // Add the unique token ID
+	claims["token_use"] = use           // Tell what the token may be used for
+	claims["exp"] = now.Add(ttl).Unix() // Set the expiration time
+	// Record when the token was issued (used by "log out everywhere"), to the millisecond: NumericDate
+	// allows fractions, and whole seconds couldn't tell a token issued right after a logout from one issued before it
+	claims["iat"] = float64(now.UnixMilli()) / 1000
+	if role != "" {
+		claims["role"] = role // Add the role of the user, read by middleware.RequireRole
+	}
 
-	// Sign the token using the secret key
-	tokenString, err := token.SignedString(jwtSecretKey)
+	// Sign the token using the active key
+	tokenString, err := token.SignedString(key.Private)
 	if err != nil {
 		// If there is an error signing the token, return it
 		return "", err
@@ -33,17 +78,44 @@ func GenerateJWT(username string) (string, error) {
 	return tokenString, nil
 }
 
+// TokenUse returns the "token_use" claim of a token
+// Tokens issued before the claim existed are access tokens
+func TokenUse(claims jwt.MapClaims) string {
+	use, ok := claims["token_use"].(string)
+	if !ok {
+		return TokenUseAccess
+	}
+	return use
+}
+
+// TokenRole returns the "role" claim of a token
+// It is empty for tokens issued before roles existed, which callers treat as a regular user
+func TokenRole(claims jwt.MapClaims) string {
+	role, _ := claims["role"].(string)
+	return role
+}
+
 // ValidateJWT validates the JWT token
 // It checks if the token is valid and returns the claims if it is.
 func ValidateJWT(tokenString string) (jwt.MapClaims, error) {
-	// Parse the token string and verify the token using the secret key
+	if keySet == nil {
+		return nil, errNoKeys
+	}
+
+	// Parse the token string and verify the token using the key named in its "kid" header
 	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Ensure that the token is using the correct signing method (HS256)
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("invalid signing method") // If the signing method is not HMAC, return an error
+		kid, _ := token.Header["kid"].(string)
+		key := keySet.Lookup(kid)
+		if key == nil {
+			return nil, errors.New("unknown signing key") // The key was never configured or has been retired
+		}
+		// Ensure that the token is using the signing method of that key, so an RS256 public key
+		// can never be used as an HMAC secret (algorithm confusion)
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("invalid signing method")
 		}
-		// Return the secret key used to sign the token so it can be validated
-		return jwtSecretKey, nil
+		// Return the key used to verify the signature
+		return key.Public, nil
 	})
 
 	// If there was an error parsing the token, return the error
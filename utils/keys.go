@@ -0,0 +1,287 @@
+package utils
+
+import (
+	"crypto/ed25519"  // For EdDSA (Ed25519) keys
+	"crypto/rsa"      // For RS256 keys
+	"crypto/sha256"   // To derive a key ID from the public key when none is configured
+	"crypto/x509"     // To parse PEM-encoded keys
+	"encoding/base64" // JWKS encodes key material with base64url
+	"encoding/pem"    // To decode PEM files
+	"errors"          // To report configuration problems
+	"fmt"             // To build error messages
+	"log"             // To warn about insecure defaults
+	"math/big"        // To encode the RSA exponent
+	"os"              // To read configuration from the environment and key files
+	"sort"            // To list the keys in a stable order
+	"strings"         // To split lists in the configuration
+	"sync"            // To protect the key set during rotation
+
+	"github.com/golang-jwt/jwt/v4" // Import the JWT library for the signing methods
+)
+
+// SigningKey is a key used to sign or verify JWTs
+// For HS256 both Private and Public hold the shared secret ([]byte).
+// For RS256 they hold *rsa.PrivateKey and *rsa.PublicKey, for EdDSA ed25519.PrivateKey and ed25519.PublicKey.
+// Private is nil for keys that are only kept to verify tokens signed before a rotation.
+type SigningKey struct {
+	ID      string            // The key ID, sent in the "kid" header of every token
+	Method  jwt.SigningMethod // The signing algorithm (HS256, RS256 or EdDSA)
+	Private interface{}       // The key used to sign tokens
+	Public  interface{}       // The key used to verify tokens
+}
+
+// KeySet holds the key currently used to sign tokens and every key still accepted for verification
+type KeySet struct {
+	mu     sync.RWMutex
+	active *SigningKey
+	keys   map[string]*SigningKey
+}
+
+// keySet is the key set used by GenerateJWT and ValidateJWT
+var keySet *KeySet
+
+// SetKeySet sets the key set used to sign and verify tokens
+// This function is called from the main app with the keys loaded from the configuration
+func SetKeySet(keys *KeySet) {
+	keySet = keys
+}
+
+// NewKeySet creates a key set that signs with the active key
+// The previous keys are only used to verify tokens that were signed with them
+func NewKeySet(active *SigningKey, previous ...*SigningKey) *KeySet {
+	keys := &KeySet{active: active, keys: make(map[string]*SigningKey)}
+	for _, key := range previous {
+		keys.keys[key.ID] = key
+	}
+	keys.keys[active.ID] = active
+	return keys
+}
+
+// Rotate makes a new key the active signing key
+// The old key stays in the set, so tokens it signed remain valid until they expire
+func (ks *KeySet) Rotate(key *SigningKey) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys[key.ID] = key
+	ks.active = key
+}
+
+// Active returns the key currently used to sign tokens
+func (ks *KeySet) Active() *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.active
+}
+
+// Lookup returns the key with the given ID, or nil if it isn't in the set
+func (ks *KeySet) Lookup(kid string) *SigningKey {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.keys[kid]
+}
+
+// JWK is a single public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`           // Key type: "RSA" or "OKP"
+	Kid string `json:"kid"`           // Key ID, matches the "kid" header of tokens
+	Use string `json:"use"`           // Always "sig"
+	Alg string `json:"alg"`           // "RS256" or "EdDSA"
+	N   string `json:"n,omitempty"`   // RSA modulus
+	E   string `json:"e,omitempty"`   // RSA public exponent
+	Crv string `json:"crv,omitempty"` // Curve for OKP keys ("Ed25519")
+	X   string `json:"x,omitempty"`   // Ed25519 public key
+}
+
+// JWKS returns the public keys of the set, in the format served at /.well-known/jwks.json
+// HMAC keys are secrets and are never included
+func (ks *KeySet) JWKS() []JWK {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	jwks := []JWK{}
+	for _, key := range ks.keys {
+		switch public := key.Public.(type) {
+		case *rsa.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "RSA",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			jwks = append(jwks, JWK{
+				Kty: "OKP",
+				Kid: key.ID,
+				Use: "sig",
+				Alg: key.Method.Alg(),
+				Crv: "Ed25519",
+				X:   base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	sort.Slice(jwks, func(i, j int) bool { return jwks[i].Kid < jwks[j].Kid })
+	return jwks
+}
+
+// JWKS returns the public keys of the configured key set
+func JWKS() []JWK {
+	if keySet == nil {
+		return []JWK{}
+	}
+	return keySet.JWKS()
+}
+
+// LoadKeySetFromEnv builds the key set from environment variables:
+//
+//	JWT_ALGORITHM         HS256 (default), RS256 or EdDSA
+//	JWT_KEY_ID            the kid of the active key (derived from the key when empty)
+//	JWT_SECRET            the shared secret, for HS256
+//	JWT_PRIVATE_KEY_FILE  a PEM private key, for RS256 and EdDSA
+//	JWT_PREVIOUS_KEYS     comma-separated kid=path pairs of PEM keys still accepted for verification
+//	JWT_PREVIOUS_SECRETS  comma-separated kid=secret pairs of HS256 secrets still accepted for verification
+//
+// When nothing is configured a random HS256 secret is generated, so tokens don't survive a restart.
+func LoadKeySetFromEnv() (*KeySet, error) {
+	algorithm := os.Getenv("JWT_ALGORITHM")
+	if algorithm == "" {
+		algorithm = "HS256"
+	}
+
+	var active *SigningKey
+	var err error
+	switch algorithm {
+	case "HS256":
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			log.Println("JWT_SECRET is not set, using a random secret: tokens won't survive a restart")
+			secret, err = GenerateSecureToken(32)
+			if err != nil {
+				return nil, err
+			}
+		}
+		active = NewHMACKey(os.Getenv("JWT_KEY_ID"), []byte(secret))
+	case "RS256", "EdDSA":
+		path := os.Getenv("JWT_PRIVATE_KEY_FILE")
+		if path == "" {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE is required for %s", algorithm)
+		}
+		active, err = LoadPEMKey(os.Getenv("JWT_KEY_ID"), path)
+		if err != nil {
+			return nil, err
+		}
+		if active.Method.Alg() != algorithm {
+			return nil, fmt.Errorf("JWT_PRIVATE_KEY_FILE holds a %s key, expected %s", active.Method.Alg(), algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported JWT_ALGORITHM %q", algorithm)
+	}
+
+	// Keys from before a rotation, still accepted to verify tokens
+	var previous []*SigningKey
+	for _, pair := range splitPairs(os.Getenv("JWT_PREVIOUS_KEYS")) {
+		key, err := LoadPEMKey(pair[0], pair[1])
+		if err != nil {
+			return nil, err
+		}
+		key.Private = nil // Old keys must never sign new tokens
+		previous = append(previous, key)
+	}
+	for _, pair := range splitPairs(os.Getenv("JWT_PREVIOUS_SECRETS")) {
+		key := NewHMACKey(pair[0], []byte(pair[1]))
+		key.Private = nil
+		previous = append(previous, key)
+	}
+
+	return NewKeySet(active, previous...), nil
+}
+
+// NewHMACKey creates an HS256 key from a shared secret
+func NewHMACKey(kid string, secret []byte) *SigningKey {
+	if kid == "" {
+		kid = deriveKeyID(secret)
+	}
+	return &SigningKey{ID: kid, Method: jwt.SigningMethodHS256, Private: secret, Public: secret}
+}
+
+// LoadPEMKey reads an RSA or Ed25519 key from a PEM file
+// The file may hold a private key (PKCS#1 or PKCS#8) or only a public key (PKIX),
+// in which case the key can only be used for verification.
+func LoadPEMKey(kid string, path string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM data found", path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	key := &SigningKey{ID: kid}
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodRS256, k, &k.PublicKey
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	case ed25519.PrivateKey:
+		key.Method, key.Private, key.Public = jwt.SigningMethodEdDSA, k, k.Public()
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	default:
+		return nil, fmt.Errorf("%s: unsupported key type %T", path, parsed)
+	}
+
+	if key.ID == "" {
+		der, err := x509.MarshalPKIXPublicKey(key.Public)
+		if err != nil {
+			return nil, err
+		}
+		key.ID = deriveKeyID(der)
+	}
+	return key, nil
+}
+
+// deriveKeyID builds a short, stable key ID from key material
+func deriveKeyID(material []byte) string {
+	sum := sha256.Sum256(material)
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
+
+// splitPairs parses a "a=b,c=d" list into pairs, ignoring empty entries
+func splitPairs(value string) [][2]string {
+	var pairs [][2]string
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		kid, rest, found := strings.Cut(entry, "=")
+		if !found {
+			log.Println("Ignoring malformed key entry (expected kid=value):", entry)
+			continue
+		}
+		pairs = append(pairs, [2]string{kid, rest})
+	}
+	return pairs
+}
+
+// errNoKeys is returned when GenerateJWT or ValidateJWT is called before SetKeySet
+var errNoKeys = errors.New("JWT signing keys are not configured")
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"errors" // To report tokens that can't be revoked
+	"math"   // To round fractional issue times to the millisecond
+	"sync"   // To protect the in-memory store from concurrent access
+	"time"   // To work with expiration and issue times
+
+	"github.com/golang-jwt/jwt/v4" // Import the JWT library to read the claims of a token
+)
+
+// RevocationStore keeps track of access tokens that must no longer be accepted
+// A single token is revoked by its "jti" claim (logout), and every token of a user
+// can be revoked at once by remembering a cut-off time (log out everywhere).
+type RevocationStore interface {
+	// RevokeToken revokes one token; expiresAt lets the store forget it once it would have expired anyway
+	RevokeToken(jti string, expiresAt time.Time) error
+	// RevokeUser revokes every token of the user issued at or before the given time
+	RevokeUser(username string, before time.Time) error
+	// IsRevoked reports whether a token with this jti, owner and issue time has been revoked
+	IsRevoked(jti string, username string, issuedAt time.Time) (bool, error)
+}
+
+// revocationStore is the store used by RevokeToken, RevokeAllTokens and IsTokenRevoked
+// It defaults to an in-memory store; main.go replaces it with the SQL-backed one
+var revocationStore RevocationStore = NewMemoryRevocationStore()
+
+// SetRevocationStore sets the store used to check and record revoked tokens
+func SetRevocationStore(store RevocationStore) {
+	revocationStore = store
+}
+
+// RevokeToken revokes the token the given claims were read from
+func RevokeToken(claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return errors.New("token has no jti claim")
+	}
+	return revocationStore.RevokeToken(jti, claimTime(claims, "exp"))
+}
+
+// RevokeAllTokens revokes every token issued to the user up to now
+func RevokeAllTokens(username string) error {
+	// Tokens carry their issue time to the millisecond, so the cut-off is truncated the same way
+	return revocationStore.RevokeUser(username, time.Now().Truncate(time.Millisecond))
+}
+
+// IsTokenRevoked checks the claims of a valid token against the revocation store
+// Tokens without a jti claim are treated as revoked, since they can't be logged out
+func IsTokenRevoked(claims jwt.MapClaims) (bool, error) {
+	jti, _ := claims["jti"].(string)
+	username, _ := claims["username"].(string)
+	if jti == "" || username == "" {
+		return true, nil
+	}
+	return revocationStore.IsRevoked(jti, username, claimTime(claims, "iat"))
+}
+
+// claimTime reads a NumericDate claim (such as "exp" or "iat") as a time.Time, to the millisecond
+func claimTime(claims jwt.MapClaims, name string) time.Time {
+	switch value := claims[name].(type) {
+	case float64:
+		return time.UnixMilli(int64(math.Round(value * 1000)))
+	case int64:
+		return time.Unix(value, 0)
+	}
+	return time.Time{}
+}
+
+// MemoryRevocationStore is a RevocationStore that keeps everything in memory
+// It is useful for tests and single-instance setups; revocations are lost on restart
+type MemoryRevocationStore struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time // jti -> expiration time of the revoked token
+	users  map[string]time.Time // username -> tokens issued at or before this time are revoked
+}
+
+// NewMemoryRevocationStore creates an empty MemoryRevocationStore
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[string]time.Time),
+	}
+}
+
+// RevokeToken records the jti as revoked and forgets tokens that have already expired
+func (s *MemoryRevocationStore) RevokeToken(jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range s.tokens {
+		if exp.Before(now) {
+			delete(s.tokens, id)
+		}
+	}
+	s.tokens[jti] = expiresAt
+	return nil
+}
+
+// RevokeUser records the cut-off time for the user's tokens
+func (s *MemoryRevocationStore) RevokeUser(username string, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[username] = before
+	return nil
+}
+
+// IsRevoked checks both the single-token and the per-user revocations
+func (s *MemoryRevocationStore) IsRevoked(jti string, username string, issuedAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.tokens[jti]; ok {
+		return true, nil
+	}
+	if before, ok := s.users[username]; ok && !issuedAt.After(before) {
+		return true, nil
+	}
+	return false, nil
+}
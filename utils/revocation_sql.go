@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"database/sql" // Import the database/sql package to interact with SQL databases
+	"time"         // To work with expiration and issue times
+)
+
+// SQLRevocationStore is a RevocationStore backed by the "revoked_tokens" and
+// "user_token_revocations" tables, so revocations are shared by every server instance
+type SQLRevocationStore struct {
+	db *sql.DB
+}
+
+// NewSQLRevocationStore creates a SQLRevocationStore using the given database connection
+func NewSQLRevocationStore(db *sql.DB) *SQLRevocationStore {
+	return &SQLRevocationStore{db: db}
+}
+
+// RevokeToken stores the jti and removes rows of tokens that have already expired
+func (s *SQLRevocationStore) RevokeToken(jti string, expiresAt time.Time) error {
+	if _, err := s.db.Exec("DELETE FROM revoked_tokens WHERE expires_at < ?", time.Now()); err != nil {
+		return err
+	}
+	_, err := s.db.Exec("INSERT IGNORE INTO revoked_tokens (jti, expires_at) VALUES (?, ?)", jti, expiresAt)
+	return err
+}
+
+// RevokeUser stores (or moves forward) the cut-off time for the user's tokens
+func (s *SQLRevocationStore) RevokeUser(username string, before time.Time) error {
+	_, err := s.db.Exec(`INSERT INTO user_token_revocations (username, revoked_before) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE revoked_before = VALUES(revoked_before)`, username, before)
+	return err
+}
+
+// IsRevoked checks both tables with a single query
+func (s *SQLRevocationStore) IsRevoked(jti string, username string, issuedAt time.Time) (bool, error) {
+	var revoked bool
+	err := s.db.QueryRow(`SELECT
+		EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = ?) OR
+		EXISTS(SELECT 1 FROM user_token_revocations WHERE username = ? AND revoked_before >= ?)`,
+		jti, username, issuedAt).Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+	return revoked, nil
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"crypto/rand"     // To generate cryptographically secure random bytes
+	"crypto/sha256"   // To hash tokens before they are stored
+	"encoding/base64" // To turn random bytes into a URL-safe string
+	"encoding/hex"    // To store the hash as a readable string
+)
+
+// GenerateSecureToken generates a random, URL-safe token
+// The size argument is the number of random bytes (32 bytes gives 256 bits of randomness)
+func GenerateSecureToken(size int) (string, error) {
+	bytes := make([]byte, size)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// HashToken hashes a token with SHA-256 and returns it as a hex string
+// We only ever store the hash of a token, so a leaked database doesn't leak usable tokens
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
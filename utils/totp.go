@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"crypto/rand"     // To generate recovery codes
+	"crypto/subtle"   // To compare codes in constant time
+	"encoding/base32" // To turn random bytes into readable recovery codes
+	"strings"         // To normalize the codes typed by users
+	"time"            // TOTP codes depend on the current time
+
+	"github.com/pquerna/otp/totp" // TOTP implementation (RFC 6238), compatible with authenticator apps
+)
+
+// TOTPIssuer is the name authenticator apps show next to the account
+const TOTPIssuer = "GO-X"
+
+// totpPeriod is how long each TOTP code is valid, in seconds (the value every authenticator app uses)
+const totpPeriod = 30
+
+// totpSkew is how many periods before and after the current one are accepted, to tolerate clock drift
+const totpSkew = 1
+
+// NewTOTPSecret generates a random TOTP secret for an account
+// It returns the base32 secret and the otpauth:// URI that authenticator apps import (usually as a QR code)
+func NewTOTPSecret(accountName string) (secret string, uri string, err error) {
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      TOTPIssuer,
+		AccountName: accountName,
+		Period:      totpPeriod,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return key.Secret(), key.URL(), nil
+}
+
+// ValidateTOTP checks a 6-digit code against a secret at the given time
+// It returns the time step the code belongs to, so callers can refuse a code that was already used.
+func ValidateTOTP(secret string, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != 6 {
+		return 0, false
+	}
+
+	step := now.Unix() / totpPeriod
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix((step+offset)*totpPeriod, 0), totp.ValidateOpts{Period: totpPeriod})
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step + offset, true
+		}
+	}
+	return 0, false
+}
+
+// GenerateRecoveryCodes generates one-time codes that replace a TOTP code when the authenticator is lost
+// Each code has 50 bits of randomness and is formatted as "xxxxx-xxxxx" to be easy to copy by hand
+func GenerateRecoveryCodes(count int) ([]string, error) {
+	codes := make([]string, count)
+	for i := range codes {
+		bytes := make([]byte, 7)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, err
+		}
+		encoded := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(bytes))[:10]
+		codes[i] = encoded[:5] + "-" + encoded[5:]
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode normalizes a recovery code as typed by a user, then hashes it for storage or lookup
+// Case, spaces and dashes don't matter
+func HashRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.NewReplacer("-", "", " ", "").Replace(code)
+	return HashToken(code)
+}